@@ -47,14 +47,19 @@ func PreloadWithConditions(db *gorm.DB) {
 	db.Preload("Comments.Pos", "published = ?", true).Find(&authors)
 }
 
-// PreloadWithVariables shows cases that won't be validated
+// PreloadWithVariables shows how relation names held in variables are handled
 func PreloadWithVariables(db *gorm.DB) {
 	var authors []Author
 
-	// ⚠️ Variable relation names cannot be validated at compile time
+	// ✅ A local assigned once from a constant is folded and validated
 	relationName := "Posts"
 	db.Preload(relationName).Find(&authors)
 
+	// ✅ Ranging over a []string literal validates every element
+	for _, rel := range []string{"Posts", "Comments"} {
+		db.Preload(rel).Find(&authors)
+	}
+
 	// ⚠️ Dynamic relation names - skipped by linter
 	relations := []string{"Posts", "Comments"}
 	for _, rel := range relations {
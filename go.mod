@@ -3,13 +3,19 @@ module github.com/your-moon/gpc
 go 1.25.0
 
 require (
+	github.com/prometheus/common v0.62.0
 	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/gorm v1.31.0
 )
 
 require (
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
 	golang.org/x/mod v0.35.0 // indirect
 	golang.org/x/sync v0.20.0 // indirect
+	google.golang.org/protobuf v1.36.1 // indirect
 )
 
 require (
@@ -17,6 +23,6 @@ require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/spf13/cobra v1.10.1
-	github.com/spf13/pflag v1.0.9 // indirect
-	golang.org/x/text v0.20.0 // indirect
+	github.com/spf13/pflag v1.0.9
+	golang.org/x/text v0.21.0 // indirect
 )
@@ -5,6 +5,7 @@ import (
 	"go/constant"
 	"go/token"
 	"go/types"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 
@@ -13,9 +14,20 @@ import (
 
 // PreloadInfo holds info about a single .Preload("X") call.
 type PreloadInfo struct {
-	Relation string // resolved string value, empty if dynamic
-	Dynamic  bool   // true if argument is not a resolvable constant
-	Line     int    // 1-based source line of the .Preload call
+	Relation    string // resolved string value, empty if dynamic (except unanchored intents)
+	Dynamic     bool   // true if argument is not a resolvable constant
+	Conditional bool   // true if Preload was given conditions after the relation
+	Suppressed  bool   // true if a //gpc:ignore directive covers this preload
+	Line        int    // 1-based source line of the relation argument
+	Column      int    // 1-based byte column of the relation argument
+	// Selects lists the columns a scope function argument selects, for
+	// checking against the relation's struct.
+	Selects []SelectColumn
+	// Expr is the preload call as written, without its receiver:
+	// `Preload("Items", "active = ?", true)`. Function literal arguments
+	// are shortened the way types.ExprString does. Empty for preloads
+	// from an option-struct field.
+	Expr string
 }
 
 // TerminalCall holds info about the terminal call (.Find, .First, etc.)
@@ -31,22 +43,79 @@ type Chain struct {
 	Terminal *TerminalCall
 	File     string
 	Pkg      *packages.Package
+	// Source is how the preloads reach Terminal: "chain" (the same call
+	// chain), "variable" (a query variable assigned earlier) or "field" (an
+	// option-struct field literal).
+	Source string
+	// Func is the function declaring the chain: "List" for a function,
+	// "Repo.List" for a method. Empty outside any function declaration.
+	Func string
 }
 
 var terminalMethods = map[string]bool{
-	"Find": true, "First": true, "FirstOrCreate": true,
+	"Find": true, "First": true, "FirstOrCreate": true, "FirstOrInit": true,
 	"Take": true, "Last": true, "Scan": true,
 }
 
 const gormPkgPath = "gorm.io/gorm"
 
+// Options tunes Collect. The zero value collects Preload call chains only.
+type Options struct {
+	// OptionFields holds path.Match patterns for struct field names whose
+	// []string literals are collected as preload intents (see
+	// collectOptionIntents), e.g. "Preloads".
+	OptionFields []string
+	// PreloadMethods names extra methods whose first argument is a relation
+	// path, like Preload's (e.g. a wrapper's "WithPreload").
+	PreloadMethods []string
+	// TerminalMethods names extra finisher methods that run a query, like
+	// Find and First (e.g. "FindInBatches"). Their first argument is the
+	// destination the model is inferred from.
+	TerminalMethods []string
+	// Exclude, when set, reports whether a file is left out of collection.
+	Exclude func(filename string) bool
+	// IgnoreBareNolint makes a //nolint directive without linter names
+	// leave gpc findings alone; by default it silences them like
+	// //nolint:gpc does.
+	IgnoreBareNolint bool
+	// Progress, when set, is called as each file is reached with the count
+	// of files reached so far and the total.
+	Progress func(done, total int)
+	// OnFile, when set, is called with each collected file's chains (maybe
+	// none) as soon as the file is done, so they can be verified while
+	// later files are still walked. Excluded files are not reported.
+	OnFile func(filename string, chains []Chain)
+}
+
 // Collect walks all packages and extracts Preload chains.
-func Collect(result *loader.Result) []Chain {
+func Collect(result *loader.Result, opts Options) []Chain {
 	var chains []Chain
+	methods := map[string]bool{"Preload": true}
+	for _, name := range opts.PreloadMethods {
+		methods[name] = true
+	}
+	terminals := map[string]bool{}
+	for name := range terminalMethods {
+		terminals[name] = true
+	}
+	for _, name := range opts.TerminalMethods {
+		terminals[name] = true
+	}
 
+	total, done := 0, 0
+	for _, pkg := range result.Packages {
+		total += len(pkg.Syntax)
+	}
 	for _, pkg := range result.Packages {
 		for _, file := range pkg.Syntax {
+			if done++; opts.Progress != nil {
+				opts.Progress(done, total)
+			}
 			fileName := pkg.Fset.Position(file.Pos()).Filename
+			if opts.Exclude != nil && opts.Exclude(fileName) {
+				continue
+			}
+			first := len(chains)
 
 			ast.Inspect(file, func(n ast.Node) bool {
 				call, ok := n.(*ast.CallExpr)
@@ -58,7 +127,13 @@ func Collect(result *loader.Result) []Chain {
 				if !ok {
 					return true
 				}
-				if !terminalMethods[sel.Sel.Name] {
+				if sel.Sel.Name == "Association" {
+					if chain, ok := associationChain(call, sel, fileName, pkg); ok {
+						chains = append(chains, chain)
+					}
+					return true
+				}
+				if !terminals[sel.Sel.Name] {
 					return true
 				}
 
@@ -78,12 +153,12 @@ func Collect(result *loader.Result) []Chain {
 				}
 
 				// Collect preloads from the inline chain
-				preloads := collectPreloads(sel.X, pkg)
+				preloads, source := collectPreloads(sel.X, pkg, methods), "chain"
 
 				// If no preloads found inline, check if the receiver is a variable
 				// that was assigned from a chain containing Preload calls
 				if len(preloads) == 0 {
-					preloads = collectPreloadsFromVariable(sel.X, file, pkg)
+					preloads, source = collectPreloadsFromVariable(sel.X, file, pkg, methods), "variable"
 				}
 
 				if len(preloads) > 0 {
@@ -92,19 +167,105 @@ func Collect(result *loader.Result) []Chain {
 						Terminal: terminal,
 						File:     fileName,
 						Pkg:      pkg,
+						Source:   source,
 					})
 				}
 
 				return true
 			})
+
+			if len(opts.OptionFields) > 0 {
+				chains = append(chains, collectOptionIntents(file, fileName, pkg, opts.OptionFields)...)
+			}
+
+			for i := first; i < len(chains); i++ {
+				if chains[i].Func == "" && chains[i].Terminal != nil {
+					chains[i].Func = enclosingFunc(file, chains[i].Terminal.Pos)
+				}
+			}
+			scanDirectives(file, pkg.Fset, opts.IgnoreBareNolint).suppress(chains[first:])
+			if opts.OnFile != nil {
+				opts.OnFile(fileName, chains[first:])
+			}
 		}
 	}
 
 	return chains
 }
 
+// enclosingFunc names the function declaration in file containing pos, as
+// Chain.Func does, or returns "" when there is none.
+func enclosingFunc(file *ast.File, pos token.Pos) string {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos < fn.End() {
+			return funcName(fn)
+		}
+	}
+	return ""
+}
+
+// funcName is fn's name, qualified by its receiver's type name for a
+// method: "Repo.List" for func (r *Repo[T]) List().
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	typ := fn.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		default:
+			return fn.Name.Name
+		}
+	}
+}
+
+// associationChain describes an association-mode call,
+// db.Model(&user).Association("Roles"), as a chain whose relation is the
+// Association argument and whose terminal is the Model call in the same
+// chain, which the model is inferred from. Unlike Preload, a relation that
+// isn't constant is left out rather than reported as dynamic: generic
+// association helpers (GORM's own included) pass it through.
+func associationChain(call *ast.CallExpr, sel *ast.SelectorExpr, fileName string, pkg *packages.Package) (Chain, bool) {
+	if len(call.Args) != 1 || !isGormDBExpr(sel.X, pkg.TypesInfo) {
+		return Chain{}, false
+	}
+	if _, ok := resolveStringArg(call.Args[0], pkg); !ok {
+		return Chain{}, false
+	}
+	for cur := sel.X; ; {
+		c, ok := cur.(*ast.CallExpr)
+		if !ok {
+			return Chain{}, false
+		}
+		s, ok := c.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return Chain{}, false
+		}
+		if s.Sel.Name == "Model" && len(c.Args) == 1 {
+			return Chain{
+				Preloads: preloadInfos(call, pkg),
+				Terminal: &TerminalCall{Method: "Model", Arg: c.Args[0], Pos: c.Pos()},
+				File:     fileName,
+				Pkg:      pkg,
+				Source:   "chain",
+			}, true
+		}
+		cur = s.X
+	}
+}
+
 // collectPreloads walks the method chain backward collecting all .Preload() calls.
-func collectPreloads(expr ast.Expr, pkg *packages.Package) []PreloadInfo {
+func collectPreloads(expr ast.Expr, pkg *packages.Package, methods map[string]bool) []PreloadInfo {
 	var preloads []PreloadInfo
 	cur := expr
 
@@ -118,31 +279,139 @@ func collectPreloads(expr ast.Expr, pkg *packages.Package) []PreloadInfo {
 			break
 		}
 
-		if sel.Sel.Name == "Preload" && len(call.Args) > 0 {
-			pi := PreloadInfo{Line: pkg.Fset.Position(call.Pos()).Line}
-			relation, ok := resolveStringArg(call.Args[0], pkg.TypesInfo)
-			if ok {
-				pi.Relation = relation
-			} else {
-				pi.Dynamic = true
-			}
-			preloads = append(preloads, pi)
+		if methods[sel.Sel.Name] && len(call.Args) > 0 {
+			// Prepend so order matches source order (outermost first)
+			preloads = append(preloadInfos(call, pkg), preloads...)
 		}
 
 		cur = sel.X
 	}
 
-	// Reverse so order matches source order (outermost first)
-	for i, j := 0, len(preloads)-1; i < j; i, j = i+1, j-1 {
-		preloads[i], preloads[j] = preloads[j], preloads[i]
+	return preloads
+}
+
+// preloadInfos describes a single .Preload call. A call whose argument is
+// the loop variable of a range over a []string literal expands to one entry
+// per element, each positioned at its element's literal.
+func preloadInfos(call *ast.CallExpr, pkg *packages.Package) []PreloadInfo {
+	// call.Pos() is the start of the whole chain, so position on the argument
+	pos := pkg.Fset.Position(call.Args[0].Pos())
+	infos := []PreloadInfo{{Dynamic: true, Line: pos.Line, Column: pos.Column}}
+	if relation, ok := resolveStringArg(call.Args[0], pkg); ok {
+		infos = []PreloadInfo{{Relation: relation, Line: pos.Line, Column: pos.Column}}
+	} else if ident, ok := call.Args[0].(*ast.Ident); ok {
+		if elts := rangeLiteralElts(ident, pkg); elts != nil {
+			infos = eltInfos(elts, pkg)
+		}
 	}
 
-	return preloads
+	if len(call.Args) > 1 {
+		selects := scopeSelects(call.Args[1], pkg)
+		for i := range infos {
+			infos[i].Conditional = true
+			infos[i].Selects = selects
+		}
+	}
+	expr := callString(call)
+	for i := range infos {
+		infos[i].Expr = expr
+	}
+	return infos
+}
+
+// callString spells a method call without its receiver: Preload("User").
+func callString(call *ast.CallExpr) string {
+	args := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = types.ExprString(arg)
+	}
+	name := types.ExprString(call.Fun)
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		name = sel.Sel.Name
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// eltInfos describes each element of a string slice literal, positioned at
+// the element itself.
+func eltInfos(elts []ast.Expr, pkg *packages.Package) []PreloadInfo {
+	var infos []PreloadInfo
+	for _, elt := range elts {
+		pos := pkg.Fset.Position(elt.Pos())
+		pi := PreloadInfo{Line: pos.Line, Column: pos.Column}
+		if relation, ok := constantString(elt, pkg.TypesInfo); ok {
+			pi.Relation = relation
+		} else {
+			pi.Dynamic = true
+		}
+		infos = append(infos, pi)
+	}
+	return infos
+}
+
+// rangeLiteralElts returns the elements of the []string composite literal
+// ranged over when ident is that range statement's value variable:
+//
+//	for _, rel := range []string{"Posts", "Comments"} { db.Preload(rel) }
+//
+// Ranges over any other expression return nil.
+func rangeLiteralElts(ident *ast.Ident, pkg *packages.Package) []ast.Expr {
+	obj, ok := pkg.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return nil
+	}
+
+	file := declFile(obj, pkg)
+	if file == nil {
+		return nil
+	}
+
+	var rng *ast.RangeStmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		if r, ok := n.(*ast.RangeStmt); ok {
+			if value, ok := r.Value.(*ast.Ident); ok && pkg.TypesInfo.Defs[value] == obj {
+				rng = r
+			}
+		}
+		return rng == nil
+	})
+	if rng == nil {
+		return nil
+	}
+
+	return stringSliceElts(rng.X, pkg.TypesInfo)
+}
+
+// stringSliceElts returns the elements of expr when it is a []string
+// composite literal, and nil otherwise.
+func stringSliceElts(expr ast.Expr, info *types.Info) []ast.Expr {
+	comp, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	slice, ok := info.TypeOf(comp).Underlying().(*types.Slice)
+	if !ok {
+		return nil
+	}
+	if basic, ok := slice.Elem().Underlying().(*types.Basic); !ok || basic.Info()&types.IsString == 0 {
+		return nil
+	}
+	return comp.Elts
+}
+
+// declFile returns the file of pkg that declares obj.
+func declFile(obj types.Object, pkg *packages.Package) *ast.File {
+	for _, f := range pkg.Syntax {
+		if f.Pos() <= obj.Pos() && obj.Pos() < f.End() {
+			return f
+		}
+	}
+	return nil
 }
 
 // resolveStringArg resolves a call argument to a string value.
-// Handles string literals, constants, and clause.Associations.
-func resolveStringArg(expr ast.Expr, info *types.Info) (string, bool) {
+// Handles clause.Associations and the static strings of staticString.
+func resolveStringArg(expr ast.Expr, pkg *packages.Package) (string, bool) {
 	// Check for clause.Associations (selector expression)
 	if sel, ok := expr.(*ast.SelectorExpr); ok {
 		if sel.Sel.Name == "Associations" {
@@ -151,8 +420,87 @@ func resolveStringArg(expr ast.Expr, info *types.Info) (string, bool) {
 			}
 		}
 	}
+	return staticString(expr, pkg)
+}
 
+// staticString evaluates a string expression built only from values known
+// before the program runs: literals and constants, single-assignment local
+// variables, concatenations of those, and fmt.Sprintf with a static format
+// of %s and %v verbs over static arguments. Concatenations of constant
+// operands ("Items" + "." + "Product") arrive already folded by the type
+// checker. Any other operand, such as a parameter or a function result,
+// leaves the expression dynamic.
+func staticString(expr ast.Expr, pkg *packages.Package) (string, bool) {
 	// Try constant evaluation (handles both literals and const refs)
+	if s, ok := constantString(expr, pkg.TypesInfo); ok {
+		return s, true
+	}
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return resolveLocalString(e, pkg)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := staticString(e.X, pkg)
+		if !ok {
+			return "", false
+		}
+		y, ok := staticString(e.Y, pkg)
+		return x + y, ok
+	case *ast.CallExpr:
+		return staticSprintf(e, pkg)
+	}
+	return "", false
+}
+
+// staticSprintf evaluates fmt.Sprintf("%s.Profile", rel) when its format
+// and arguments are static strings and the format has only %s, %v and %%.
+func staticSprintf(call *ast.CallExpr, pkg *packages.Package) (string, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return "", false
+	}
+	fn, ok := pkg.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" || fn.Name() != "Sprintf" {
+		return "", false
+	}
+	format, ok := staticString(call.Args[0], pkg)
+	if !ok {
+		return "", false
+	}
+	var b strings.Builder
+	args := call.Args[1:]
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i++; i == len(format) {
+			return "", false
+		}
+		switch format[i] {
+		case '%':
+			b.WriteByte('%')
+		case 's', 'v':
+			if len(args) == 0 {
+				return "", false
+			}
+			arg, ok := staticString(args[0], pkg)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(arg)
+			args = args[1:]
+		default:
+			return "", false
+		}
+	}
+	return b.String(), len(args) == 0
+}
+
+// constantString returns the value of a constant string expression.
+func constantString(expr ast.Expr, info *types.Info) (string, bool) {
 	tv, ok := info.Types[expr]
 	if ok && tv.Value != nil && tv.Value.Kind() == constant.String {
 		return constant.StringVal(tv.Value), true
@@ -160,10 +508,92 @@ func resolveStringArg(expr ast.Expr, info *types.Info) (string, bool) {
 	return "", false
 }
 
+// resolveLocalString folds a local variable that is written exactly once,
+// from a static string (see staticString), and never reassigned or
+// address-taken:
+//
+//	rel := "Posts"
+//	db.Preload(rel)
+//
+// Package-level variables are never folded since any file may write them.
+func resolveLocalString(ident *ast.Ident, pkg *packages.Package) (string, bool) {
+	obj, ok := pkg.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || obj.Pkg() == nil || obj.Parent() == nil || obj.Parent() == obj.Pkg().Scope() {
+		return "", false
+	}
+
+	file := declFile(obj, pkg)
+	if file == nil {
+		return "", false
+	}
+
+	var (
+		value  string
+		writes int
+		folded = true
+	)
+	isObj := func(e ast.Expr) bool {
+		id, ok := e.(*ast.Ident)
+		return ok && pkg.TypesInfo.ObjectOf(id) == obj
+	}
+	record := func(rhs ast.Expr) {
+		writes++
+		s, ok := "", false
+		if rhs != nil {
+			s, ok = staticString(rhs, pkg)
+		}
+		if !ok {
+			folded = false
+		}
+		value = s
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if !isObj(lhs) {
+					continue
+				}
+				if (n.Tok == token.DEFINE || n.Tok == token.ASSIGN) && len(n.Lhs) == len(n.Rhs) {
+					record(n.Rhs[i])
+				} else {
+					record(nil)
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if pkg.TypesInfo.Defs[name] != obj {
+					continue
+				}
+				if len(n.Values) == len(n.Names) {
+					record(n.Values[i])
+				} else {
+					record(nil)
+				}
+			}
+		case *ast.RangeStmt:
+			if isObj(n.Key) || isObj(n.Value) {
+				record(nil)
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && isObj(n.X) {
+				record(nil)
+			}
+		}
+		return true
+	})
+
+	if writes != 1 || !folded {
+		return "", false
+	}
+	return value, true
+}
+
 // collectPreloadsFromVariable resolves preloads when the receiver is a variable
 // e.g., query := db.Preload("User"); query.Find(&orders)
 // Also handles struct literals: orm := &QueryBuilder{DB: db.Preload("User")}
-func collectPreloadsFromVariable(expr ast.Expr, file *ast.File, pkg *packages.Package) []PreloadInfo {
+func collectPreloadsFromVariable(expr ast.Expr, file *ast.File, pkg *packages.Package, methods map[string]bool) []PreloadInfo {
 	ident, ok := expr.(*ast.Ident)
 	if !ok {
 		return nil
@@ -194,17 +624,17 @@ func collectPreloadsFromVariable(expr ast.Expr, file *ast.File, pkg *packages.Pa
 			rhs := assign.Rhs[i]
 			// Direct call chain: query := db.Preload("User")
 			if call, ok := rhs.(*ast.CallExpr); ok {
-				preloads = append(preloads, collectPreloadsFromCall(call, pkg)...)
+				preloads = append(preloads, collectPreloadsFromCall(call, pkg, methods)...)
 			}
 			// Struct literal with &: orm := &QueryBuilder{DB: db.Preload("X")}
 			if unary, ok := rhs.(*ast.UnaryExpr); ok {
 				if comp, ok := unary.X.(*ast.CompositeLit); ok {
-					preloads = append(preloads, collectPreloadsFromCompositeLit(comp, pkg)...)
+					preloads = append(preloads, collectPreloadsFromCompositeLit(comp, pkg, methods)...)
 				}
 			}
 			// Struct literal without &: orm := QueryBuilder{DB: db.Preload("X")}
 			if comp, ok := rhs.(*ast.CompositeLit); ok {
-				preloads = append(preloads, collectPreloadsFromCompositeLit(comp, pkg)...)
+				preloads = append(preloads, collectPreloadsFromCompositeLit(comp, pkg, methods)...)
 			}
 		}
 		return true
@@ -215,7 +645,7 @@ func collectPreloadsFromVariable(expr ast.Expr, file *ast.File, pkg *packages.Pa
 
 // collectPreloadsFromCompositeLit extracts preloads from struct literal fields
 // that are *gorm.DB typed (including embedded fields).
-func collectPreloadsFromCompositeLit(comp *ast.CompositeLit, pkg *packages.Package) []PreloadInfo {
+func collectPreloadsFromCompositeLit(comp *ast.CompositeLit, pkg *packages.Package, methods map[string]bool) []PreloadInfo {
 	var preloads []PreloadInfo
 	for _, elt := range comp.Elts {
 		kv, ok := elt.(*ast.KeyValueExpr)
@@ -226,7 +656,7 @@ func collectPreloadsFromCompositeLit(comp *ast.CompositeLit, pkg *packages.Packa
 		valType := pkg.TypesInfo.TypeOf(kv.Value)
 		if valType != nil && isGormDBType(valType) {
 			if call, ok := kv.Value.(*ast.CallExpr); ok {
-				preloads = append(preloads, collectPreloadsFromCall(call, pkg)...)
+				preloads = append(preloads, collectPreloadsFromCall(call, pkg, methods)...)
 			}
 		}
 	}
@@ -234,7 +664,7 @@ func collectPreloadsFromCompositeLit(comp *ast.CompositeLit, pkg *packages.Packa
 }
 
 // collectPreloadsFromCall extracts preloads from a call expression tree.
-func collectPreloadsFromCall(call *ast.CallExpr, pkg *packages.Package) []PreloadInfo {
+func collectPreloadsFromCall(call *ast.CallExpr, pkg *packages.Package, methods map[string]bool) []PreloadInfo {
 	var preloads []PreloadInfo
 
 	sel, ok := call.Fun.(*ast.SelectorExpr)
@@ -242,20 +672,13 @@ func collectPreloadsFromCall(call *ast.CallExpr, pkg *packages.Package) []Preloa
 		return nil
 	}
 
-	if sel.Sel.Name == "Preload" && len(call.Args) > 0 {
-		pi := PreloadInfo{Line: pkg.Fset.Position(call.Pos()).Line}
-		relation, ok := resolveStringArg(call.Args[0], pkg.TypesInfo)
-		if ok {
-			pi.Relation = relation
-		} else {
-			pi.Dynamic = true
-		}
-		preloads = append(preloads, pi)
+	if methods[sel.Sel.Name] && len(call.Args) > 0 {
+		preloads = append(preloads, preloadInfos(call, pkg)...)
 	}
 
 	// Recurse into the receiver
 	if innerCall, ok := sel.X.(*ast.CallExpr); ok {
-		inner := collectPreloadsFromCall(innerCall, pkg)
+		inner := collectPreloadsFromCall(innerCall, pkg, methods)
 		preloads = append(inner, preloads...)
 	}
 
@@ -271,14 +694,24 @@ func isGormDBExpr(expr ast.Expr, info *types.Info) bool {
 	return isGormDBType(typ)
 }
 
+// maxEmbedDepth bounds how deep isGormDBType descends through embedded
+// fields. Together with its visited set it keeps self-embedding types
+// (type Node struct{ *Node }) from recursing forever.
+const maxEmbedDepth = 16
+
 func isGormDBType(typ types.Type) bool {
-	if ptr, ok := typ.(*types.Pointer); ok {
+	return isGormDBTypeIn(typ, map[*types.Named]bool{}, 0)
+}
+
+func isGormDBTypeIn(typ types.Type, visited map[*types.Named]bool, depth int) bool {
+	if ptr, ok := types.Unalias(typ).(*types.Pointer); ok {
 		typ = ptr.Elem()
 	}
-	named, ok := typ.(*types.Named)
-	if !ok {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok || visited[named] || depth > maxEmbedDepth {
 		return false
 	}
+	visited[named] = true
 	obj := named.Obj()
 	if obj.Name() == "DB" && obj.Pkg() != nil && obj.Pkg().Path() == gormPkgPath {
 		return true
@@ -293,7 +726,7 @@ func isGormDBType(typ types.Type) bool {
 		if !field.Embedded() {
 			continue
 		}
-		if isGormDBType(field.Type()) {
+		if isGormDBTypeIn(field.Type(), visited, depth+1) {
 			return true
 		}
 	}
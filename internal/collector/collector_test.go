@@ -1,6 +1,8 @@
 package collector
 
 import (
+	"go/types"
+	"reflect"
 	"testing"
 
 	"github.com/your-moon/gpc/internal/loader"
@@ -36,7 +38,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -90,7 +92,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -135,7 +137,7 @@ func GetData(db *gorm.DB) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 2 {
 		t.Fatalf("expected 2 chains, got %d", len(chains))
 	}
@@ -170,7 +172,7 @@ func GetData(db *gorm.DB) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain (only gorm), got %d", len(chains))
 	}
@@ -206,7 +208,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -237,7 +239,7 @@ func GetData(db *gorm.DB, field string) {
 		t.Fatalf("Load failed: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -249,6 +251,148 @@ func GetData(db *gorm.DB, field string) {
 	}
 }
 
+func TestCollect_ConcatenatedPreloadArg(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+const prefix = "Items"
+
+type Order struct {
+	ID int64
+}
+
+func GetOrders(db *gorm.DB, suffix string) {
+	var orders []Order
+	db.Preload("Items" + "." + "Product").Find(&orders)
+	db.Preload(prefix + ".Product").Find(&orders)
+	db.Preload("Items." + suffix).Find(&orders)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	chains := Collect(result, Options{})
+	if len(chains) != 3 {
+		t.Fatalf("expected 3 chains, got %d", len(chains))
+	}
+	for i, want := range []string{"Items.Product", "Items.Product"} {
+		if got := chains[i].Preloads[0].Relation; got != want {
+			t.Errorf("chain %d: expected folded relation '%s', got '%s'", i, want, got)
+		}
+	}
+	if !chains[2].Preloads[0].Dynamic {
+		t.Error("expected Dynamic=true when an operand is a variable")
+	}
+	if chains[2].Preloads[0].Relation != "" {
+		t.Errorf("expected no partial relation for dynamic concatenation, got '%s'", chains[2].Preloads[0].Relation)
+	}
+}
+
+func TestCollect_StaticStringExpressions(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const prefix = "Items"
+
+type Order struct {
+	ID int64
+}
+
+func GetOrders(db *gorm.DB, suffix string) {
+	var orders []Order
+	base := prefix + "."
+	db.Preload(base + "Product").Find(&orders)
+	db.Preload(fmt.Sprintf("%s.Product", prefix)).Find(&orders)
+	db.Preload(fmt.Sprintf("%v%s", base, "Product.Category")).Find(&orders)
+	nested := fmt.Sprintf("%s.%s", prefix, "Product")
+	db.Preload(nested).Find(&orders)
+
+	db.Preload(fmt.Sprintf("%s.Product", suffix)).Find(&orders)
+	db.Preload(fmt.Sprintf("%d.Product", 1)).Find(&orders)
+	db.Preload(fmt.Sprintf("%s.%s", prefix)).Find(&orders)
+	db.Preload(base + suffix).Find(&orders)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	chains := Collect(result, Options{})
+	want := []string{"Items.Product", "Items.Product", "Items.Product.Category", "Items.Product", "", "", "", ""}
+	if len(chains) != len(want) {
+		t.Fatalf("expected %d chains, got %d", len(want), len(chains))
+	}
+	for i, w := range want {
+		got := chains[i].Preloads[0]
+		if got.Relation != w || got.Dynamic != (w == "") {
+			t.Errorf("chain %d: expected relation %q (dynamic %v), got %+v", i, w, w == "", got)
+		}
+	}
+}
+
+func TestCollect_LocalConstantVariable(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Author struct {
+	ID int64
+}
+
+func GetAuthors(db *gorm.DB, flag bool) {
+	var authors []Author
+
+	relationName := "Posts"
+	db.Preload(relationName).Find(&authors)
+
+	reassigned := "Posts"
+	if flag {
+		reassigned = "Comments"
+	}
+	db.Preload(reassigned).Find(&authors)
+
+	var declared = "Comments"
+	db.Preload(declared).Find(&authors)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	chains := Collect(result, Options{})
+	if len(chains) != 3 {
+		t.Fatalf("expected 3 chains, got %d", len(chains))
+	}
+	if got := chains[0].Preloads[0]; got.Dynamic || got.Relation != "Posts" {
+		t.Errorf("expected single-assignment variable folded to 'Posts', got %+v", got)
+	}
+	if !chains[1].Preloads[0].Dynamic {
+		t.Error("expected Dynamic=true for a reassigned variable")
+	}
+	if got := chains[2].Preloads[0]; got.Dynamic || got.Relation != "Comments" {
+		t.Errorf("expected var-declared variable folded to 'Comments', got %+v", got)
+	}
+}
+
 func TestCollect_ClauseAssociations(t *testing.T) {
 	dir := testutil.CreateTestModule(t, map[string]string{
 		"main.go": `package main
@@ -279,7 +423,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -317,7 +461,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -362,7 +506,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -415,7 +559,7 @@ func GetOrders(db *gorm.DB) {
 		t.Fatalf("Load: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -451,7 +595,7 @@ func GetUsers(db *gorm.DB) {
 		t.Fatalf("Load: %v", err)
 	}
 
-	chains := Collect(result)
+	chains := Collect(result, Options{})
 	if len(chains) != 1 {
 		t.Fatalf("expected 1 chain, got %d", len(chains))
 	}
@@ -459,3 +603,395 @@ func GetUsers(db *gorm.DB) {
 		t.Errorf("expected 'Name', got '%s'", chains[0].Preloads[0].Relation)
 	}
 }
+
+func TestCollect_SelfEmbeddingReceiver(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Node struct {
+	*Node
+	Name string
+}
+
+func (n *Node) Preload(query string) *Node { return n }
+func (n *Node) Find(dest any)              {}
+
+type Order struct {
+	ID int64
+}
+
+func GetOrders(db *gorm.DB, n *Node) {
+	var orders []Order
+	n.Preload("Items").Find(&orders)
+	db.Preload("Items").Find(&orders)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// A type that embeds itself is not *gorm.DB, however deep it is looked into
+	chains := Collect(result, Options{})
+	if len(chains) != 1 {
+		t.Fatalf("expected 1 chain (only gorm), got %d", len(chains))
+	}
+}
+
+func TestCollect_ConditionalPreload(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Orders", "state = ?", "paid").Preload("Profile").Find(&users)
+	db.Preload("Orders", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Find(&users)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	chains := Collect(result, Options{})
+	if len(chains) != 2 || len(chains[0].Preloads) != 2 {
+		t.Fatalf("expected 2 chains, the first with 2 preloads, got %+v", chains)
+	}
+	if !chains[0].Preloads[0].Conditional {
+		t.Error("expected Orders to be conditional")
+	}
+	if chains[0].Preloads[1].Conditional {
+		t.Error("expected Profile to be unconditional")
+	}
+
+	want := []string{
+		`Preload("Orders", "state = ?", "paid")`,
+		`Preload("Profile")`,
+		`Preload("Orders", (func(db *gorm.DB) *gorm.DB literal))`,
+	}
+	got := []string{chains[0].Preloads[0].Expr, chains[0].Preloads[1].Expr, chains[1].Preloads[0].Expr}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("preload expressions = %q, want %q", got, want)
+	}
+}
+
+func TestCollect_IgnoreDirectives(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Orders").Find(&users) //gpc:ignore legacy relation
+	db.Preload("Profile").Find(&users) // gpc:ignore is not a directive
+	db.Preload("Orders").
+		Preload("Profile"). //gpc:ignore
+		Find(&users)
+}
+`,
+		"legacy.go": `//gpc:ignore-file generated code
+package main
+
+import "gorm.io/gorm"
+
+func GetLegacy(db *gorm.DB) {
+	var users []User
+	db.Preload("Anything").Find(&users)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var got []bool
+	for _, chain := range Collect(result, Options{}) {
+		for _, p := range chain.Preloads {
+			got = append(got, p.Suppressed)
+		}
+	}
+	// legacy.go sorts before main.go
+	want := []bool{true, true, false, false, true}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d preloads, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("preload %d: Suppressed = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCollect_NolintDirectives(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Orders").Find(&users) //nolint:gpc // legacy relation
+	//nolint:preloadcheck
+	db.Preload("Profile").Find(&users)
+	db.Preload("Orders").Find(&users) //nolint:errcheck
+	db.Preload("Roles").Find(&users) //nolint
+	db.Preload("Teams").Find(&users) //nolint:errcheck,all
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want []bool
+	}{
+		{"bare nolint counts", Options{}, []bool{true, true, false, true, true}},
+		{"bare nolint ignored", Options{IgnoreBareNolint: true}, []bool{true, true, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []bool
+			for _, chain := range Collect(result, tt.opts) {
+				for _, p := range chain.Preloads {
+					got = append(got, p.Suppressed)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d preloads, got %d", len(tt.want), len(got))
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("preload %d: Suppressed = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCollect_TerminalMethods(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Orders").FindInBatches(&users, 100, func(tx *gorm.DB, batch int) error {
+		return nil
+	})
+	db.Preload("Profile").Find(&users)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if chains := Collect(result, Options{}); len(chains) != 1 {
+		t.Fatalf("expected 1 chain without extra finishers, got %d", len(chains))
+	}
+	chains := Collect(result, Options{TerminalMethods: []string{"FindInBatches"}})
+	if len(chains) != 2 {
+		t.Fatalf("expected 2 chains, got %d", len(chains))
+	}
+	if chains[0].Terminal.Method != "FindInBatches" {
+		t.Errorf("expected FindInBatches terminal, got %s", chains[0].Terminal.Method)
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []selectColumn
+	}{
+		{"id, name", []selectColumn{{"id", 0}, {"name", 4}}},
+		{"staff.id,`full_name`", []selectColumn{{"id", 6}, {"full_name", 10}}},
+		{"name AS n, COUNT(*) AS total, *", []selectColumn{{"name", 0}}},
+		{"1, id + 1, \"nam\"", []selectColumn{{"nam", 12}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := selectColumns(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectColumns(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCollect_ScopeSelects(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+const cols = "id"
+
+type Staff struct {
+	ID   int64
+	Name string
+}
+
+type Machine struct {
+	Staff Staff
+}
+
+func GetMachines(db *gorm.DB) {
+	var machines []Machine
+	db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, nam").Select(cols, "staff.name").Select([]string{"name"})
+	}).Preload("Staff", "active = ?", true).Find(&machines)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	chains := Collect(result, Options{})
+	if len(chains) != 1 || len(chains[0].Preloads) != 2 {
+		t.Fatalf("expected 1 chain with 2 preloads, got %+v", chains)
+	}
+	// cols is a constant, placed at the identifier
+	want := []SelectColumn{
+		{Name: "id", Line: 19, Column: 21},
+		{Name: "nam", Line: 19, Column: 25},
+		{Name: "id", Line: 19, Column: 38},
+		{Name: "name", Line: 19, Column: 51},
+		{Name: "name", Line: 19, Column: 75},
+	}
+	if got := chains[0].Preloads[0].Selects; !reflect.DeepEqual(got, want) {
+		t.Errorf("Selects = %+v, want %+v", got, want)
+	}
+	if got := chains[0].Preloads[1].Selects; got != nil {
+		t.Errorf("expected no selects for a condition argument, got %+v", got)
+	}
+}
+
+func TestCollect_Association(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Role struct {
+	ID int64
+}
+
+type Staff struct {
+	ID    int64
+	Roles []Role
+}
+
+func GetRoles(db *gorm.DB, staff *Staff) {
+	var roles []Role
+	db.Model(staff).Where("id = ?", 1).Association("Role").Find(&roles)
+	db.Association("Roles")
+	name := func() string { return "Roles" }()
+	db.Model(staff).Association(name)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	chains := Collect(result, Options{})
+	if len(chains) != 1 {
+		t.Fatalf("expected 1 chain (none without Model or for a dynamic name), got %d", len(chains))
+	}
+	c := chains[0]
+	if c.Terminal.Method != "Model" || types.ExprString(c.Terminal.Arg) != "staff" {
+		t.Errorf("expected terminal Model(staff), got %s(%s)", c.Terminal.Method, types.ExprString(c.Terminal.Arg))
+	}
+	if len(c.Preloads) != 1 || c.Preloads[0].Relation != "Role" || c.Preloads[0].Line != 16 {
+		t.Errorf("expected relation Role on line 16, got %+v", c.Preloads)
+	}
+}
+
+func TestCollect_Func(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Repo[T any] struct {
+	db *gorm.DB
+}
+
+func List(db *gorm.DB) {
+	var users []User
+	db.Preload("A").Find(&users)
+}
+
+func (r *Repo[T]) Get() {
+	var users []User
+	func() {
+		r.db.Preload("B").Find(&users)
+	}()
+}
+
+type Opts struct {
+	Preloads []string
+}
+
+func run(db *gorm.DB, opts Opts, dest any) {}
+
+func (Opts) Query(db *gorm.DB) {
+	var users []User
+	run(db, Opts{Preloads: []string{"C"}}, &users)
+}
+`,
+	})
+
+	result, err := loader.Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range Collect(result, Options{OptionFields: []string{"Preloads"}}) {
+		got[c.Preloads[0].Relation] = c.Func
+	}
+	want := map[string]string{"A": "List", "B": "Repo.Get", "C": "Opts.Query"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Func by relation = %v, want %v", got, want)
+	}
+}
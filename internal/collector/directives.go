@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// directives holds a file's suppression comments:
+//
+//   - a trailing "//gpc:ignore [reason]" silences findings on its line;
+//   - "//gpc:ignore-file [reason]" anywhere in the file silences all of them;
+//   - "//nolint:gpc" or "//nolint:preloadcheck" (golangci-lint style) silences
+//     its own line, or the next line when the comment stands alone.
+//
+// A bare "//nolint" names every linter and counts unless ignoreBareNolint.
+type directives struct {
+	file  bool
+	lines map[int]bool
+}
+
+// nolintNames are the linter names a //nolint directive can use for gpc.
+var nolintNames = map[string]bool{"gpc": true, "preloadcheck": true, "all": true}
+
+func scanDirectives(file *ast.File, fset *token.FileSet, ignoreBareNolint bool) directives {
+	d := directives{lines: map[int]bool{}}
+	var code map[int]bool
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			line := fset.Position(c.Slash).Line
+			switch name, arg := directiveName(c.Text); name {
+			case "gpc:ignore":
+				d.lines[line] = true
+			case "gpc:ignore-file":
+				d.file = true
+			case "nolint":
+				if !nolintApplies(arg, ignoreBareNolint) {
+					continue
+				}
+				if code == nil {
+					code = codeLines(file, fset)
+				}
+				if code[line] {
+					d.lines[line] = true
+				} else {
+					d.lines[line+1] = true
+				}
+			}
+		}
+	}
+	return d
+}
+
+// directiveName returns the name of a directive comment, written without a
+// space after the slashes the way Go directives are, and for nolint the
+// linter list after its colon ("//nolint:gpc,errcheck" is "nolint",
+// "gpc,errcheck"). Other comments return "".
+func directiveName(text string) (name, arg string) {
+	body, ok := strings.CutPrefix(text, "//")
+	if !ok || body == "" || body[0] == ' ' {
+		return "", ""
+	}
+	word, _, _ := strings.Cut(body, " ")
+	if rest, ok := strings.CutPrefix(word, "nolint"); ok && (rest == "" || rest[0] == ':') {
+		return "nolint", strings.TrimPrefix(rest, ":")
+	}
+	return word, ""
+}
+
+// nolintApplies reports whether a //nolint directive with the given linter
+// list covers gpc.
+func nolintApplies(linters string, ignoreBare bool) bool {
+	if linters == "" {
+		return !ignoreBare
+	}
+	for _, name := range strings.Split(linters, ",") {
+		if nolintNames[strings.TrimSpace(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLines returns the lines on which some syntax node starts or ends,
+// i.e. the lines where a comment would be trailing rather than standalone.
+func codeLines(file *ast.File, fset *token.FileSet) map[int]bool {
+	lines := map[int]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Comment, *ast.CommentGroup, *ast.File:
+			return n != nil
+		}
+		lines[fset.Position(n.Pos()).Line] = true
+		lines[fset.Position(n.End()).Line] = true
+		return true
+	})
+	return lines
+}
+
+// suppress marks the preloads of chains that a directive covers.
+func (d directives) suppress(chains []Chain) {
+	for i := range chains {
+		for j := range chains[i].Preloads {
+			p := &chains[i].Preloads[j]
+			p.Suppressed = d.file || d.lines[p.Line]
+		}
+	}
+}
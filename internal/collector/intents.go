@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// collectOptionIntents finds []string literals assigned to option-struct
+// fields whose names match one of fields, the way services hand relation
+// names to a generic query runner:
+//
+//	opts := QueryOpts{Preloads: []string{"Items.Product", "Staff"}}
+//	var invoices []Invoice
+//	runQuery(db, opts, &invoices)
+//
+// Each literal becomes a chain anchored to the first &x argument of the call
+// in the same function that receives the options. When no such call exists
+// the intents are kept, relation strings included, but marked dynamic.
+func collectOptionIntents(file *ast.File, fileName string, pkg *packages.Package, fields []string) []Chain {
+	var chains []Chain
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			comp, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			for _, elt := range comp.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok || !matchesField(kv.Key, fields) {
+					continue
+				}
+				elts := stringSliceElts(kv.Value, pkg.TypesInfo)
+				if elts == nil {
+					continue
+				}
+				chain := Chain{
+					Preloads: eltInfos(elts, pkg),
+					Terminal: anchorIntent(fn.Body, comp, pkg),
+					File:     fileName,
+					Pkg:      pkg,
+					Source:   "field",
+					Func:     funcName(fn),
+				}
+				if chain.Terminal == nil {
+					for i := range chain.Preloads {
+						chain.Preloads[i].Dynamic = true
+					}
+				}
+				chains = append(chains, chain)
+			}
+			return true
+		})
+	}
+
+	return chains
+}
+
+func matchesField(key ast.Expr, fields []string) bool {
+	ident, ok := key.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, pattern := range fields {
+		if ok, _ := path.Match(pattern, ident.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anchorIntent finds the call in body that receives the options literal comp
+// (directly or through the variable it is assigned to) and returns its first
+// other &x argument as the terminal whose type pins the model.
+func anchorIntent(body *ast.BlockStmt, comp *ast.CompositeLit, pkg *packages.Package) *TerminalCall {
+	isComp := func(e ast.Expr) bool {
+		if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			e = u.X
+		}
+		return e == comp
+	}
+
+	// The variable holding the options, if the literal is assigned to one.
+	var holder ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		if assign, ok := n.(*ast.AssignStmt); ok && len(assign.Lhs) == len(assign.Rhs) {
+			for i, rhs := range assign.Rhs {
+				if isComp(rhs) {
+					holder = assign.Lhs[i]
+				}
+			}
+		}
+		return holder == nil
+	})
+	isHolder := func(e ast.Expr) bool {
+		if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			e = u.X
+		}
+		id, ok := e.(*ast.Ident)
+		h, hok := holder.(*ast.Ident)
+		return ok && hok && pkg.TypesInfo.ObjectOf(id) == pkg.TypesInfo.ObjectOf(h)
+	}
+
+	var terminal *TerminalCall
+	ast.Inspect(body, func(n ast.Node) bool {
+		if terminal != nil {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		receives := false
+		for _, arg := range call.Args {
+			receives = receives || isComp(arg) || isHolder(arg)
+		}
+		if !receives {
+			return true
+		}
+		for _, arg := range call.Args {
+			u, ok := arg.(*ast.UnaryExpr)
+			if ok && u.Op == token.AND && !isComp(arg) && !isHolder(arg) {
+				terminal = &TerminalCall{Method: calleeName(call), Arg: arg, Pos: call.Pos()}
+				break
+			}
+		}
+		return true
+	})
+	return terminal
+}
+
+func calleeName(call *ast.CallExpr) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SelectColumn is a column named by a Select call in a Preload scope:
+//
+//	db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+//		return db.Select("id, name")
+//	})
+type SelectColumn struct {
+	Name   string // column name, without table qualifier or quoting
+	Line   int    // 1-based source line of the column
+	Column int    // 1-based byte column of the column
+}
+
+// scopeSelects returns the columns named by constant Select arguments on a
+// *gorm.DB in the body of scope, when it is a function literal. Select
+// takes "id, name", several strings, or a []string literal. Nested
+// function literals are not searched.
+func scopeSelects(scope ast.Expr, pkg *packages.Package) []SelectColumn {
+	lit, ok := scope.(*ast.FuncLit)
+	if !ok {
+		return nil
+	}
+	var cols []SelectColumn
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Select" || !isGormDBExpr(sel.X, pkg.TypesInfo) {
+			return true
+		}
+		var args []ast.Expr
+		for _, arg := range call.Args {
+			if elts := stringSliceElts(arg, pkg.TypesInfo); elts != nil {
+				args = append(args, elts...)
+			} else {
+				args = append(args, arg)
+			}
+		}
+		for _, arg := range args {
+			value, ok := constantString(arg, pkg.TypesInfo)
+			if !ok {
+				continue
+			}
+			pos := pkg.Fset.Position(arg.Pos())
+			// Columns can be placed exactly within a literal without escapes
+			bl, exact := arg.(*ast.BasicLit)
+			exact = exact && len(bl.Value) >= 2 && bl.Value[1:len(bl.Value)-1] == value
+			for _, c := range selectColumns(value) {
+				col := SelectColumn{Name: c.name, Line: pos.Line, Column: pos.Column}
+				if exact && !strings.Contains(value[:c.offset], "\n") {
+					col.Column += 1 + c.offset
+				}
+				cols = append(cols, col)
+			}
+		}
+		return true
+	})
+	// Chained Selects are visited outermost (last) first
+	sort.SliceStable(cols, func(i, j int) bool {
+		if cols[i].Line != cols[j].Line {
+			return cols[i].Line < cols[j].Line
+		}
+		return cols[i].Column < cols[j].Column
+	})
+	return cols
+}
+
+type selectColumn struct {
+	name   string
+	offset int // byte offset of name in the Select string
+}
+
+// selectColumns splits a Select string into plain column names. A table
+// qualifier ("users.name") and an alias ("name AS n") are dropped; "*",
+// expressions ("COUNT(*)") and anything else that isn't a bare column are
+// skipped.
+func selectColumns(s string) []selectColumn {
+	var cols []selectColumn
+	start := 0
+	for _, part := range strings.Split(s, ",") {
+		offset := start
+		start += len(part) + 1
+		fields := strings.Fields(part)
+		switch {
+		case len(fields) == 1:
+		case len(fields) == 3 && strings.EqualFold(fields[1], "as"):
+		default:
+			continue
+		}
+		expr := fields[0]
+		if strings.ContainsAny(expr, "()*'+-/<>=?") {
+			continue
+		}
+		offset += strings.Index(part, expr)
+		if i := strings.LastIndex(expr, "."); i >= 0 {
+			offset += i + 1
+			expr = expr[i+1:]
+		}
+		name := strings.Trim(expr, "`\"")
+		if name == "" || name[0] >= '0' && name[0] <= '9' {
+			continue
+		}
+		cols = append(cols, selectColumn{name: name, offset: offset + strings.Index(expr, name)})
+	}
+	return cols
+}
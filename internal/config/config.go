@@ -0,0 +1,125 @@
+// Package config reads .gpc.yaml files, which check gpc's flag settings
+// into a repository. Each top-level key is a flag's long name:
+//
+//	format: junit
+//	file: reports/gpc.xml
+//	strict: true
+//	finishers: [FindInBatches]
+//	ignore-models: ["Legacy*"]
+//	severity:
+//	  has-many-depth: error
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name Find looks for.
+const FileName = ".gpc.yaml"
+
+// File is a parsed config file.
+type File struct {
+	Path string
+	// Keys lists the top-level keys in file order.
+	Keys []string
+	// Values holds each key's value as flag strings: one for a scalar, one
+	// per item for a list, and one "name=value" per entry for a mapping.
+	Values map[string][]string
+}
+
+// Find returns the config file for dir: dir's own, or the nearest one in
+// its parents up to the module root (the first directory with a go.mod).
+// It returns "" when there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load parses the config file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	f := &File{Path: path, Values: map[string][]string{}}
+	if len(doc.Content) == 0 {
+		return f, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: want a mapping of flag names to values", path)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		values, err := flatten(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, key.Line, key.Value, err)
+		}
+		if _, dup := f.Values[key.Value]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate key %s", path, key.Line, key.Value)
+		}
+		f.Keys = append(f.Keys, key.Value)
+		f.Values[key.Value] = values
+	}
+	return f, nil
+}
+
+// flatten turns a value node into flag strings.
+func flatten(n *yaml.Node) ([]string, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil, nil
+		}
+		return []string{n.Value}, nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(n.Content))
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, errors.New("list items must be plain values")
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	case yaml.MappingNode:
+		values := make([]string, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if v.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("%s: value must be a plain value", k.Value)
+			}
+			values = append(values, k.Value+"="+v.Value)
+		}
+		return values, nil
+	}
+	return nil, errors.New("unsupported value")
+}
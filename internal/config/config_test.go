@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	module := filepath.Join(root, "module")
+	write(t, filepath.Join(root, FileName), "strict: true\n") // outside the module
+	write(t, filepath.Join(module, "go.mod"), "module example.com/app\n")
+	nested := filepath.Join(module, "internal", "db")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Find(nested)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if got != "" {
+		t.Errorf("expected discovery to stop at the module root, got %s", got)
+	}
+
+	write(t, filepath.Join(module, FileName), "strict: true\n")
+	got, err = Find(nested)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if want := filepath.Join(module, FileName); got != want {
+		t.Errorf("Find = %s, want %s", got, want)
+	}
+
+	write(t, filepath.Join(nested, FileName), "strict: false\n")
+	got, err = Find(nested)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if want := filepath.Join(nested, FileName); got != want {
+		t.Errorf("Find = %s, want %s", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write(t, path, `# checked-in gpc settings
+format: junit
+strict: true
+exclude:
+models: [Invoice, "Trip*"]
+severity:
+  has-many-depth: error
+  redundant-preload: warning
+`)
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	wantKeys := []string{"format", "strict", "exclude", "models", "severity"}
+	if !reflect.DeepEqual(f.Keys, wantKeys) {
+		t.Errorf("Keys = %v, want %v", f.Keys, wantKeys)
+	}
+	want := map[string][]string{
+		"format":   {"junit"},
+		"strict":   {"true"},
+		"exclude":  nil,
+		"models":   {"Invoice", "Trip*"},
+		"severity": {"has-many-depth=error", "redundant-preload=warning"},
+	}
+	if !reflect.DeepEqual(f.Values, want) {
+		t.Errorf("Values = %v, want %v", f.Values, want)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not a mapping", "- format\n"},
+		{"nested list", "models: [[Invoice]]\n"},
+		{"duplicate key", "format: json\nformat: junit\n"},
+		{"bad yaml", "format: [json\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			write(t, path, tt.content)
+			if _, err := Load(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
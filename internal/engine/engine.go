@@ -1,20 +1,255 @@
 package engine
 
 import (
+	"fmt"
+	"go/types"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
 	"github.com/your-moon/gpc/internal/collector"
+	"github.com/your-moon/gpc/internal/exclude"
 	"github.com/your-moon/gpc/internal/loader"
 	"github.com/your-moon/gpc/internal/models"
 	"github.com/your-moon/gpc/internal/relations"
 )
 
+// Options configures a pipeline run. The zero value runs plain verification.
+type Options struct {
+	// Patterns restricts the run to the named packages (import paths or
+	// go-style patterns such as "./..."), resolved relative to dir. Empty
+	// means every package under dir.
+	Patterns []string
+	// Tests includes _test.go files in the analysis.
+	Tests bool
+	// IncludeVendor also collects preloads in vendored packages the
+	// analyzed packages import. Like the go command, package patterns
+	// skip vendor, testdata, and directories starting with "." or "_".
+	IncludeVendor bool
+	// Funcs, when non-empty, keeps only the chains declared in functions
+	// matching one of these path.Match patterns, against the name ("List")
+	// or, for a method, either "Repo.List" or "List".
+	Funcs []string
+	// Exclude holds glob patterns (see package exclude) of files, relative
+	// to dir, whose preloads are not collected. Their types still resolve.
+	// Files ignored by a .gpcignore in dir are left out the same way.
+	Exclude []string
+	// Debug, when set, receives timestamped diagnostic lines: a header
+	// with counts and timing per pipeline pass, and how many files each
+	// exclude pattern skipped.
+	Debug io.Writer
+	// Verbose, when set, receives a timestamped line per verified preload.
+	Verbose io.Writer
+	// Progress, when set, is told the run's stage as it advances: "load"
+	// (0 of 0) before packages are loaded, then "collect" with the files
+	// reached out of all files.
+	Progress func(stage string, done, total int)
+	// OnFile, when set, receives each file's results (maybe none) as soon
+	// as the file is verified, before the run completes.
+	OnFile  func(filename string, results []models.PreloadResult)
+	Collect collector.Options
+	Verify  relations.Options
+}
+
+// Run is the outcome of a pipeline run.
+type Run struct {
+	Results []models.PreloadResult
+	// SkippedTestFiles counts the _test.go files left out because
+	// Options.Tests was not set.
+	SkippedTestFiles int
+	// Files are the files preloads were collected from, in load order.
+	Files []string
+	// Structs counts the named struct types declared in each loaded
+	// package, by package path.
+	Structs map[string]int
+}
+
 // Analyze runs the full v2 analysis pipeline on the given directory.
-func Analyze(dir string) ([]models.PreloadResult, error) {
-	result, err := loader.Load(dir)
+func Analyze(dir string, opts Options) (*Run, error) {
+	debug := logger{w: opts.Debug, tag: "debug"}
+	if opts.Progress != nil {
+		opts.Progress("load", 0, 0)
+		opts.Collect.Progress = func(done, total int) { opts.Progress("collect", done, total) }
+	}
+	result, excluded, err := load(dir, opts, debug)
 	if err != nil {
 		return nil, err
 	}
+	if excluded != nil {
+		opts.Collect.Exclude = excluded.Excluded
+	}
+
+	// Each file is verified as soon as it is collected, for OnFile
+	verbose := logger{w: opts.Verbose, tag: "verbose"}
+	var results []models.PreloadResult
+	var verifying time.Duration
+	var files []string
+	chains, preloads := 0, 0
+	opts.Collect.OnFile = func(filename string, fileChains []collector.Chain) {
+		files = append(files, filename)
+		if len(opts.Funcs) > 0 {
+			fileChains = inFuncs(fileChains, opts.Funcs)
+		}
+		chains += len(fileChains)
+		for _, c := range fileChains {
+			preloads += len(c.Preloads)
+		}
+		start := time.Now()
+		fileResults := relations.Verify(fileChains, opts.Verify)
+		verifying += time.Since(start)
+		for _, r := range fileResults {
+			status := r.Status
+			if r.Kind != "" {
+				status += " (" + r.Kind + ")"
+			}
+			verbose.printf("%s:%d: %s on %s: %s", r.File, r.Line, r.Relation, r.Model, status)
+		}
+		results = append(results, fileResults...)
+		if opts.OnFile != nil {
+			opts.OnFile(filename, fileResults)
+		}
+	}
+
+	start := time.Now()
+	collector.Collect(result, opts.Collect)
+	debug.printf("collect: %d chain(s), %d preload(s) in %s", chains, preloads, (time.Since(start) - verifying).Round(time.Millisecond))
+
+	if excluded != nil {
+		for _, pat := range opts.Exclude {
+			debug.printf("exclude %q skipped %d file(s)", pat, excluded.Skipped(pat))
+		}
+		if n := excluded.Skipped(exclude.IgnoreFile); n > 0 {
+			debug.printf("%s skipped %d file(s)", exclude.IgnoreFile, n)
+		}
+	}
+	debug.printf("verify: %d result(s) in %s", len(results), verifying.Round(time.Millisecond))
+
+	return &Run{
+		Results:          results,
+		SkippedTestFiles: result.SkippedTestFiles,
+		Files:            files,
+		Structs:          countStructs(result.Packages),
+	}, nil
+}
+
+// countStructs counts the package-level named struct types of each of
+// pkgs, by package path.
+func countStructs(pkgs []*packages.Package) map[string]int {
+	counts := map[string]int{}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		n := 0
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			if tn, ok := scope.Lookup(name).(*types.TypeName); ok && !tn.IsAlias() {
+				if _, ok := tn.Type().Underlying().(*types.Struct); ok {
+					n++
+				}
+			}
+		}
+		counts[pkg.Types.Path()] = n
+	}
+	return counts
+}
+
+// Files returns the files a run with opts would collect preloads from,
+// in load order: those of the loaded packages that opts.Exclude and the
+// .gpcignore leave in.
+// Nothing is collected or verified.
+func Files(dir string, opts Options) ([]string, error) {
+	result, excluded, err := load(dir, opts, logger{w: opts.Debug, tag: "debug"})
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, pkg := range result.Packages {
+		for _, file := range pkg.Syntax {
+			name := pkg.Fset.Position(file.Pos()).Filename
+			if excluded == nil || !excluded.Excluded(name) {
+				files = append(files, name)
+			}
+		}
+	}
+	return files, nil
+}
+
+// load loads the packages of a run, with vendored imports under
+// opts.IncludeVendor, and builds the matcher of opts.Exclude and dir's
+// .gpcignore (nil when there is neither).
+func load(dir string, opts Options, debug logger) (*loader.Result, *exclude.Matcher, error) {
+	loadPkgs := loader.Load
+	if opts.Tests {
+		loadPkgs = loader.LoadWithTests
+	}
+	start := time.Now()
+	result, err := loadPkgs(dir, opts.Patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.IncludeVendor {
+		result.Packages = append(result.Packages, result.Vendored()...)
+	}
+	debug.printf("load %s %v: %d package(s) in %s", dir, opts.Patterns, len(result.Packages), since(start))
 
-	chains := collector.Collect(result)
+	ignore, err := exclude.ReadIgnore(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(opts.Exclude) == 0 && ignore == nil {
+		return result, nil, nil
+	}
+	excluded, err := exclude.New(dir, opts.Exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, excluded.WithIgnore(ignore), nil
+}
+
+// inFuncs keeps the chains whose Func matches one of patterns, as
+// Options.Funcs describes.
+func inFuncs(chains []collector.Chain, patterns []string) []collector.Chain {
+	var kept []collector.Chain
+	for _, c := range chains {
+		if matchFunc(c.Func, patterns) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func matchFunc(name string, patterns []string) bool {
+	_, method, _ := strings.Cut(name, ".")
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pat, method); ok && method != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// logger writes diagnostic lines to w, each stamped with the time of day
+// so long runs can be profiled by eye. A nil w drops them.
+type logger struct {
+	w   io.Writer
+	tag string
+}
+
+func (l logger) printf(format string, args ...any) {
+	if l.w == nil {
+		return
+	}
+	fmt.Fprintf(l.w, "%s gpc: %s: %s\n", time.Now().Format("15:04:05.000"), l.tag, fmt.Sprintf(format, args...))
+}
 
-	return relations.Verify(chains), nil
+// since is the time elapsed from start, rounded for display.
+func since(start time.Time) time.Duration {
+	return time.Since(start).Round(time.Millisecond)
 }
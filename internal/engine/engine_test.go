@@ -1,8 +1,17 @@
 package engine
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/your-moon/gpc/internal/models"
 	"github.com/your-moon/gpc/internal/testutil"
 )
 
@@ -44,10 +53,11 @@ func GetOrders(db *gorm.DB) {
 `,
 	})
 
-	results, err := Analyze(dir)
+	run, err := Analyze(dir, Options{})
 	if err != nil {
 		t.Fatalf("Analyze: %v", err)
 	}
+	results := run.Results
 
 	if len(results) != 5 {
 		t.Fatalf("expected 5 results, got %d", len(results))
@@ -99,10 +109,11 @@ type Order struct {
 `,
 	})
 
-	results, err := Analyze(dir)
+	run, err := Analyze(dir, Options{})
 	if err != nil {
 		t.Fatalf("Analyze: %v", err)
 	}
+	results := run.Results
 
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
@@ -139,10 +150,11 @@ func GetOrders(db *gorm.DB) {
 `,
 	})
 
-	results, err := Analyze(dir)
+	run, err := Analyze(dir, Options{})
 	if err != nil {
 		t.Fatalf("Analyze: %v", err)
 	}
+	results := run.Results
 
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
@@ -172,12 +184,496 @@ func GetUsers(db *gorm.DB) {
 `,
 	})
 
-	results, err := Analyze(dir)
+	run, err := Analyze(dir, Options{})
 	if err != nil {
 		t.Fatalf("Analyze: %v", err)
 	}
+	results := run.Results
 
 	if len(results) != 0 {
 		t.Errorf("expected 0 results, got %d", len(results))
 	}
 }
+
+func TestAnalyze_Patterns(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Profile").Find(&users)
+}
+`,
+		"repo/repo.go": `package repo
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	ID   int64
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+}
+`,
+	})
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     int
+	}{
+		{"wildcard", []string{"./..."}, 2},
+		{"import path", []string{"testmod/repo"}, 1},
+		{"relative wildcard", []string{"./repo/..."}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Analyze(dir, Options{Patterns: tt.patterns})
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			results := run.Results
+			if len(results) != tt.want {
+				t.Fatalf("expected %d results, got %d", tt.want, len(results))
+			}
+		})
+	}
+}
+
+func TestAnalyze_Exclude(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func GetOrders(db *gorm.DB) {
+	var orders []models.Order
+	db.Preload("User").Find(&orders)
+}
+`,
+		"models/models.go": `package models
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	ID   int64
+	User User
+}
+`,
+		"internal/mocks/mock.go": `package mocks
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func MockOrders(db *gorm.DB) {
+	var orders []models.Order
+	db.Preload("Whatever").Find(&orders)
+}
+`,
+	})
+
+	var debug bytes.Buffer
+	run, err := Analyze(dir, Options{
+		Exclude: []string{"**/mocks/**", "models/*.go", "legacy/**"},
+		Debug:   &debug,
+	})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	results := run.Results
+	if len(run.Files) != 1 || run.Structs["testmod/models"] != 2 {
+		t.Errorf("expected 1 file scanned and 2 structs in testmod/models, got %v and %v", run.Files, run.Structs)
+	}
+	// Excluding the models file doesn't stop its types from resolving
+	if len(results) != 1 || results[0].Status != "valid" {
+		t.Fatalf("expected 1 valid result, got %+v", results)
+	}
+
+	want := []string{
+		`gpc: debug: exclude "**/mocks/**" skipped 1 file(s)`,
+		`gpc: debug: exclude "models/*.go" skipped 1 file(s)`,
+		`gpc: debug: exclude "legacy/**" skipped 0 file(s)`,
+	}
+	if got := debugLines(debug.String(), "exclude"); !slices.Equal(got, want) {
+		t.Errorf("debug output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Analyze(dir, Options{Exclude: []string{"[mocks"}}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestAnalyze_IgnoreFile(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		".gpcignore": "# generated\ngen/\n*_mock.go\n!keep_mock.go\n",
+		"main.go": `package main
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/gen"
+)
+
+func GetOrders(db *gorm.DB) {
+	var orders []gen.Order
+	db.Preload("User").Find(&orders)
+	db.Preload("Nope").Find(&orders)
+}
+`,
+		"gen/models.go": `package gen
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	ID   int64
+	User User
+}
+
+func Generated(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Whatever").Find(&orders)
+}
+`,
+		"repo/order_mock.go": `package repo
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/gen"
+)
+
+func Mock(db *gorm.DB) {
+	var orders []gen.Order
+	db.Preload("Whatever").Find(&orders)
+}
+`,
+		"repo/keep_mock.go": `package repo
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/gen"
+)
+
+func Keep(db *gorm.DB) {
+	var orders []gen.Order
+	db.Preload("User").Find(&orders)
+}
+`,
+	})
+
+	var debug bytes.Buffer
+	run, err := Analyze(dir, Options{Debug: &debug})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	var got []string
+	for _, r := range run.Results {
+		got = append(got, filepath.Base(r.File)+" "+r.Relation+" "+r.Status)
+	}
+	slices.Sort(got)
+	want := []string{"keep_mock.go User valid", "main.go Nope error", "main.go User valid"}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	if got := debugLines(debug.String(), ".gpcignore"); !slices.Equal(got, []string{"gpc: debug: .gpcignore skipped 2 file(s)"}) {
+		t.Errorf("unexpected debug output %v", got)
+	}
+
+	files, err := Files(dir, Options{})
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected main.go and keep_mock.go listed, got %v", files)
+	}
+}
+
+func TestAnalyze_SkippedDirectories(t *testing.T) {
+	bad := func(pkg string) string {
+		return `package ` + pkg + `
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Nope").Find(&users)
+}
+`
+	}
+	dir := testutil.CreateTestModule(t, map[string]string{
+		".hidden/hidden.go":    bad("hidden"),
+		"_old/old.go":          bad("old"),
+		"testdata/fixture.go":  bad("fixture"),
+		"internal/.git/x/x.go": bad("x"),
+		"legacy/legacy.go":     bad("legacy"),
+		"legacy/go.mod":        "module example.com/legacy\n\ngo 1.25\n\nrequire gorm.io/gorm v1.31.0\n",
+	})
+	// Vendor the legacy module the way "go mod vendor" leaves it
+	main := `package main
+
+import (
+	"gorm.io/gorm"
+
+	"example.com/legacy"
+)
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Profile").Find(&users)
+	legacy.GetUsers(db)
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"mod", "edit", "-require=example.com/legacy@v0.0.0", "-replace=example.com/legacy=./legacy"},
+		{"mod", "tidy"},
+		{"mod", "vendor"},
+	} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %v: %s\n%v", args, out, err)
+		}
+	}
+
+	// Build from vendor/ whatever the environment's GOFLAGS say
+	t.Setenv("GOFLAGS", "-mod=vendor")
+
+	run, err := Analyze(dir, Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	results := run.Results
+	if len(results) != 1 || results[0].Relation != "Profile" {
+		t.Errorf("expected only main.go's preload, got %+v", results)
+	}
+
+	run, err = Analyze(dir, Options{IncludeVendor: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	results = run.Results
+	var vendored []string
+	for _, r := range results {
+		if strings.Contains(filepath.ToSlash(r.File), "/vendor/") {
+			vendored = append(vendored, r.Relation)
+		}
+	}
+	if len(results) != 2 || len(vendored) != 1 || vendored[0] != "Nope" {
+		t.Errorf("expected main.go's and the vendored preload with IncludeVendor, got %+v", results)
+	}
+}
+
+func TestAnalyze_Diagnostics(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Preload("Usr").Find(&orders)
+}
+`,
+	})
+
+	var debug, verbose bytes.Buffer
+	var stages []string
+	progress := func(stage string, done, total int) {
+		stages = append(stages, fmt.Sprintf("%s %d/%d", stage, done, total))
+	}
+	if _, err := Analyze(dir, Options{Debug: &debug, Verbose: &verbose, Progress: progress}); err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if want := []string{"load 0/0", "collect 1/1"}; !slices.Equal(stages, want) {
+		t.Errorf("progress stages = %v, want %v", stages, want)
+	}
+
+	stamp := regexp.MustCompile(`^\d\d:\d\d:\d\d\.\d{3} gpc: debug: (load|collect|verify)`)
+	lines := strings.Split(strings.TrimSpace(debug.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a line per pass, got:\n%s", debug.String())
+	}
+	for _, line := range lines {
+		if !stamp.MatchString(line) {
+			t.Errorf("unexpected debug line %q", line)
+		}
+	}
+	if !strings.Contains(lines[1], "1 chain(s), 2 preload(s)") || !strings.Contains(lines[2], "2 result(s)") {
+		t.Errorf("unexpected pass stats:\n%s", debug.String())
+	}
+
+	want := []string{
+		"gpc: verbose: " + filepath.Join(dir, "main.go") + ":15: User on main.Order: valid",
+		"gpc: verbose: " + filepath.Join(dir, "main.go") + ":15: Usr on main.Order: error (not-found)",
+	}
+	if got := debugLines(verbose.String(), ""); !slices.Equal(got, want) {
+		t.Errorf("verbose output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// debugLines strips the timestamps from diagnostic output and keeps the
+// lines containing substr.
+func debugLines(out, substr string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		_, line, _ = strings.Cut(line, " ")
+		if strings.Contains(line, substr) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestAnalyze_OnFile(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"a.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Preload("Usr").Find(&orders)
+}
+`,
+		"b.go": `package main
+
+func main() {}
+`,
+	})
+
+	var files []string
+	total := 0
+	run, err := Analyze(dir, Options{OnFile: func(filename string, results []models.PreloadResult) {
+		files = append(files, filepath.Base(filename))
+		for _, r := range results {
+			if r.File != filename {
+				t.Errorf("result for %s reported with %s", r.File, filename)
+			}
+		}
+		total += len(results)
+	}})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if want := []string{"a.go", "b.go"}; !slices.Equal(files, want) {
+		t.Errorf("OnFile files = %v, want %v", files, want)
+	}
+	if total != 2 || len(run.Results) != 2 {
+		t.Errorf("expected 2 results streamed and returned, got %d and %d", total, len(run.Results))
+	}
+}
+
+func TestAnalyze_Funcs(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+type Repo struct {
+	db *gorm.DB
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Usr").Find(&orders)
+}
+
+func (r Repo) List() {
+	var orders []Order
+	r.db.Preload("User").Find(&orders)
+}
+
+func (r Repo) ListAll() {
+	var orders []Order
+	r.db.Preload("Customer").Find(&orders)
+}
+`,
+	})
+
+	tests := []struct {
+		funcs []string
+		want  []string
+	}{
+		{[]string{"GetOrders"}, []string{"Usr"}},
+		{[]string{"List"}, []string{"User"}},
+		{[]string{"Repo.*"}, []string{"User", "Customer"}},
+		{[]string{"GetOrders", "ListAll"}, []string{"Usr", "Customer"}},
+		{[]string{"Missing"}, nil},
+	}
+	for _, tt := range tests {
+		run, err := Analyze(dir, Options{Funcs: tt.funcs})
+		if err != nil {
+			t.Fatalf("Analyze: %v", err)
+		}
+		var got []string
+		for _, r := range run.Results {
+			got = append(got, r.Relation)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Funcs %v: got %v, want %v", tt.funcs, got, tt.want)
+		}
+	}
+}
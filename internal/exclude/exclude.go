@@ -0,0 +1,120 @@
+// Package exclude matches source files against --exclude glob patterns
+// and the rules of a .gpcignore file.
+package exclude
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Matcher matches files against a set of patterns and counts the files
+// each pattern excluded.
+type Matcher struct {
+	root     string
+	patterns []string
+	ignore   *Ignore
+	skipped  map[string]int
+}
+
+// New returns a Matcher for patterns. Relative patterns are matched
+// against a file's path relative to root, absolute ones against its
+// absolute path.
+func New(root string, patterns []string) (*Matcher, error) {
+	for _, pat := range patterns {
+		if err := validate(pat); err != nil {
+			return nil, err
+		}
+	}
+	return &Matcher{root: root, patterns: patterns, skipped: map[string]int{}}, nil
+}
+
+// WithIgnore makes m also exclude the files ig ignores, counted under
+// IgnoreFile, and returns m.
+func (m *Matcher) WithIgnore(ig *Ignore) *Matcher {
+	m.ignore = ig
+	return m
+}
+
+// Excluded reports whether filename matches a pattern, counting it against
+// the first one that does, or is ignored by the Matcher's Ignore.
+func (m *Matcher) Excluded(filename string) bool {
+	pat, ok := m.match(filename)
+	if !ok && m.ignore != nil && m.ignore.Ignored(filename) {
+		pat, ok = IgnoreFile, true
+	}
+	if ok {
+		m.skipped[pat]++
+	}
+	return ok
+}
+
+// Matches reports whether filename matches a pattern, without counting it,
+// for patterns that select files rather than exclude them.
+func (m *Matcher) Matches(filename string) bool {
+	_, ok := m.match(filename)
+	return ok
+}
+
+// match returns the first pattern filename matches.
+func (m *Matcher) match(filename string) (string, bool) {
+	rel, err := filepath.Rel(m.root, filename)
+	if err != nil {
+		rel = filename
+	}
+	rel, abs := filepath.ToSlash(rel), filepath.ToSlash(filename)
+	for _, pat := range m.patterns {
+		name := rel
+		if filepath.IsAbs(pat) {
+			name = abs
+		}
+		if Match(filepath.ToSlash(pat), name) {
+			return pat, true
+		}
+	}
+	return "", false
+}
+
+// Skipped returns how many files pattern, or IgnoreFile, has excluded so
+// far.
+func (m *Matcher) Skipped(pattern string) int {
+	return m.skipped[pattern]
+}
+
+// Match reports whether the slash-separated name matches pattern. Pattern
+// segments are path.Match patterns, except "**", which matches any number
+// of segments (including none): "**/mocks/**", "internal/legacy/*.go".
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func validate(pattern string) error {
+	for _, seg := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package exclude
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/mocks/**", "internal/mocks/db.go", true},
+		{"**/mocks/**", "mocks/db.go", true},
+		{"**/mocks/**", "internal/mocksdb/db.go", false},
+		{"internal/legacy/*.go", "internal/legacy/order.go", true},
+		{"internal/legacy/*.go", "internal/legacy/v1/order.go", false},
+		{"**/*.pb.go", "api/v1/order.pb.go", true},
+		{"**/*.pb.go", "order.pb.go", true},
+		{"*.pb.go", "api/order.pb.go", false},
+		{"sandbox/**", "sandbox", true},
+		{"sandbox/**", "sandboxes/x.go", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	abs := filepath.Join(root, "gen")
+	m, err := New(root, []string{"**/mocks/**", filepath.Join(abs, "*.go")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	files := map[string]bool{
+		filepath.Join(root, "internal", "mocks", "a.go"): true,
+		filepath.Join(root, "mocks", "b.go"):             true,
+		filepath.Join(abs, "order.go"):                   true,
+		filepath.Join(root, "order.go"):                  false,
+	}
+	for file, want := range files {
+		if got := m.Matches(file); got != want {
+			t.Errorf("Matches(%s) = %v, want %v", file, got, want)
+		}
+		if got := m.Excluded(file); got != want {
+			t.Errorf("Excluded(%s) = %v, want %v", file, got, want)
+		}
+	}
+	if got := m.Skipped("**/mocks/**"); got != 2 {
+		t.Errorf("expected 2 files skipped by **/mocks/**, got %d", got)
+	}
+	if got := m.Skipped(filepath.Join(abs, "*.go")); got != 1 {
+		t.Errorf("expected 1 file skipped by the absolute pattern, got %d", got)
+	}
+
+	if _, err := New(root, []string{"a/[b"}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
@@ -0,0 +1,112 @@
+package exclude
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the file of gitignore-style patterns, at the root of an
+// analyzed directory, naming files whose preloads are not collected.
+const IgnoreFile = ".gpcignore"
+
+// Ignore matches files against the rules of an IgnoreFile, as git matches
+// a top-level .gitignore: the last rule to match a path decides, "!"
+// negates a rule, and a file under an ignored directory stays ignored.
+type Ignore struct {
+	root  string
+	rules []rule
+}
+
+// rule is a line of an IgnoreFile, with pattern in Match syntax.
+type rule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// ReadIgnore reads the IgnoreFile in root. It returns nil, and no error,
+// when there is none.
+func ReadIgnore(root string) (*Ignore, error) {
+	f, err := os.Open(filepath.Join(root, IgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseIgnore(root, f)
+}
+
+// ParseIgnore parses gitignore-style lines from r, for paths relative to
+// root. Blank lines and lines starting with "#" are skipped; a leading
+// backslash escapes a literal "#" or "!". A pattern without a slash other
+// than a trailing one matches at any depth; a trailing slash matches only
+// directories.
+func ParseIgnore(root string, r io.Reader) (*Ignore, error) {
+	ig := &Ignore{root: root}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var ru rule
+		if ru.negate = strings.HasPrefix(line, "!"); ru.negate {
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if ru.dirOnly = strings.HasSuffix(line, "/"); ru.dirOnly {
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		ru.pattern = strings.TrimPrefix(line, "/")
+		if err := validate(ru.pattern); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", IgnoreFile, n, err)
+		}
+		ig.rules = append(ig.rules, ru)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", IgnoreFile, err)
+	}
+	return ig, nil
+}
+
+// Ignored reports whether filename, or a directory it is in, is ignored.
+// Files outside root never are.
+func (ig *Ignore) Ignored(filename string) bool {
+	rel, err := filepath.Rel(ig.root, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i < len(segments); i++ {
+		if ig.match(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return ig.match(strings.Join(segments, "/"), false)
+}
+
+// match applies the rules to the slash-separated path name; the last one
+// that matches decides.
+func (ig *Ignore) match(name string, dir bool) bool {
+	ignored := false
+	for _, ru := range ig.rules {
+		if (dir || !ru.dirOnly) && Match(ru.pattern, name) {
+			ignored = !ru.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,80 @@
+package exclude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	root := t.TempDir()
+	ig, err := ParseIgnore(root, strings.NewReader(`# generated code
+*.pb.go
+!keep.pb.go
+/vendor_old/
+gen/
+third_party/**/*.go
+\#odd.go
+
+!gen/keep.go
+`))
+	if err != nil {
+		t.Fatalf("ParseIgnore: %v", err)
+	}
+
+	files := map[string]bool{
+		"api/order.pb.go":          true,
+		"order.pb.go":              true,
+		"api/keep.pb.go":           false,
+		"vendor_old/x.go":          true,
+		"internal/vendor_old/x.go": false,
+		"gen/a.go":                 true,
+		"internal/gen/b.go":        true,
+		"gen/keep.go":              true, // its directory stays ignored
+		"gen.go":                   false,
+		"third_party/x/y.go":       true,
+		"#odd.go":                  true,
+		"order.go":                 false,
+	}
+	for file, want := range files {
+		if got := ig.Ignored(filepath.Join(root, filepath.FromSlash(file))); got != want {
+			t.Errorf("Ignored(%s) = %v, want %v", file, got, want)
+		}
+	}
+	if ig.Ignored(filepath.Join(filepath.Dir(root), "order.pb.go")) {
+		t.Error("expected files outside root not to be ignored")
+	}
+
+	if _, err := ParseIgnore(root, strings.NewReader("ok.go\na/[b\n")); err == nil || !strings.Contains(err.Error(), ".gpcignore:2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+}
+
+func TestReadIgnore(t *testing.T) {
+	root := t.TempDir()
+	if ig, err := ReadIgnore(root); ig != nil || err != nil {
+		t.Fatalf("expected nil, nil without a %s, got %v, %v", IgnoreFile, ig, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, IgnoreFile), []byte("mocks/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := ReadIgnore(root)
+	if err != nil {
+		t.Fatalf("ReadIgnore: %v", err)
+	}
+
+	m, err := New(root, []string{"legacy/*.go"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	m.WithIgnore(ig)
+	for file, want := range map[string]bool{"legacy/a.go": true, "mocks/db.go": true, "order.go": false} {
+		if got := m.Excluded(filepath.Join(root, filepath.FromSlash(file))); got != want {
+			t.Errorf("Excluded(%s) = %v, want %v", file, got, want)
+		}
+	}
+	if got := m.Skipped(IgnoreFile); got != 1 {
+		t.Errorf("expected 1 file skipped by %s, got %d", IgnoreFile, got)
+	}
+}
@@ -0,0 +1,146 @@
+// Package gitdiff reads the files and lines changed relative to a git ref,
+// for --diff and --diff-lines.
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive range of line numbers in a file's new version.
+type Range struct {
+	Start, End int
+}
+
+// Diff holds the files a diff adds to or changes, as absolute paths, and
+// the lines changed in each of them.
+type Diff struct {
+	Files []string
+	Lines map[string][]Range
+}
+
+// Changed diffs ref...HEAD in the repository containing dir. Deleted files
+// are left out; renamed files are listed under their new name.
+func Changed(dir, ref string) (*Diff, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(dir, "diff", "--no-color", "--no-ext-diff", "-U0", "--diff-filter=d", ref+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(strings.TrimSpace(string(top)), bytes.NewReader(out))
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// Parse reads a unified diff with paths relative to root. Only the "+++",
+// "rename to" and hunk headers are used, so -U0 output is enough.
+func Parse(root string, r io.Reader) (*Diff, error) {
+	d := &Diff{Lines: map[string][]Range{}}
+	file := ""
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				file = ""
+				continue
+			}
+			file = d.add(root, strings.TrimPrefix(name, "b/"))
+		case strings.HasPrefix(line, "rename to "):
+			// A pure rename has no "+++" header
+			file = d.add(root, strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			rg, err := hunk(line)
+			if err != nil {
+				return nil, err
+			}
+			if rg.End >= rg.Start {
+				d.Lines[file] = append(d.Lines[file], rg)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// add lists the file at name, relative to root, unless it was just listed
+// (a rename with edits has both a "rename to" and a "+++" header).
+func (d *Diff) add(root, name string) string {
+	file := filepath.Join(root, filepath.FromSlash(name))
+	if n := len(d.Files); n == 0 || d.Files[n-1] != file {
+		d.Files = append(d.Files, file)
+	}
+	return file
+}
+
+// hunk returns the new-file range of a "@@ -a,b +c,d @@" header. A hunk
+// that only deletes lines has an empty range.
+func hunk(header string) (Range, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return Range{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+	start, count, found := strings.Cut(fields[2][1:], ",")
+	s, err := strconv.Atoi(start)
+	if err != nil {
+		return Range{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+	n := 1
+	if found {
+		if n, err = strconv.Atoi(count); err != nil {
+			return Range{}, fmt.Errorf("malformed hunk header %q", header)
+		}
+	}
+	return Range{Start: s, End: s + n - 1}, nil
+}
+
+// Contains reports whether line of file was changed.
+func (d *Diff) Contains(file string, line int) bool {
+	for _, rg := range d.Lines[file] {
+		if line >= rg.Start && line <= rg.End {
+			return true
+		}
+	}
+	return false
+}
+
+// GoFiles returns the changed files ending in .go that still exist.
+func (d *Diff) GoFiles() []string {
+	var files []string
+	for _, f := range d.Files {
+		if !strings.HasSuffix(f, ".go") {
+			continue
+		}
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
@@ -0,0 +1,139 @@
+package gitdiff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `diff --git a/repo/order.go b/repo/order.go
+index 1111111..2222222 100644
+--- a/repo/order.go
++++ b/repo/order.go
+@@ -3 +3 @@ package repo
+-old
++new
+@@ -10,0 +11,3 @@ func Get() {
++a
++b
++c
+@@ -20,2 +22,0 @@ func Put() {
+-gone
+-gone
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++
+`
+	d, err := Parse("/src", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, added := filepath.FromSlash("/src/repo/order.go"), filepath.FromSlash("/src/new.go")
+	if want := []string{order, added}; !reflect.DeepEqual(d.Files, want) {
+		t.Errorf("Files = %v, want %v", d.Files, want)
+	}
+	if want := []Range{{3, 3}, {11, 13}}; !reflect.DeepEqual(d.Lines[order], want) {
+		t.Errorf("Lines[order] = %v, want %v", d.Lines[order], want)
+	}
+
+	tests := []struct {
+		file string
+		line int
+		want bool
+	}{
+		{order, 3, true},
+		{order, 4, false},
+		{order, 13, true},
+		{order, 22, false},
+		{added, 1, true},
+		{filepath.FromSlash("/src/old.go"), 1, false},
+	}
+	for _, tt := range tests {
+		if got := d.Contains(tt.file, tt.line); got != tt.want {
+			t.Errorf("Contains(%s, %d) = %v, want %v", tt.file, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParse_MalformedHunk(t *testing.T) {
+	if _, err := Parse("/src", strings.NewReader("+++ b/a.go\n@@ -1 +x @@\n")); err == nil {
+		t.Error("expected an error for a malformed hunk header")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("keep.go", "package a\n\nfunc A() {}\n")
+	write("delete.go", "package a\n")
+	write("rename.go", "package a\n\nfunc R() {}\n")
+	write("edit.go", "package a\n\nfunc E() {}\n\n// one\n// two\n// three\n")
+	run("add", "-A")
+	run("commit", "-qm", "base")
+	run("checkout", "-qb", "feature")
+	write("keep.go", "package a\n\nfunc A() {}\n\nfunc B() {}\n")
+	write("sub/README.md", "docs\n")
+	run("rm", "-q", "delete.go")
+	run("mv", "rename.go", "sub/renamed.go")
+	run("mv", "edit.go", "sub/edited.go")
+	write("sub/edited.go", "package a\n\nfunc E() {}\n\n// one\n// two\n// three\n// four\n")
+	run("add", "-A")
+	run("commit", "-qm", "change")
+
+	d, err := Changed(filepath.Join(dir, "sub"), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range d.GoFiles() {
+		rel, _ := filepath.Rel(dir, f)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if want := []string{"keep.go", "sub/edited.go", "sub/renamed.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GoFiles = %v, want %v", got, want)
+	}
+	if keep := filepath.Join(dir, "keep.go"); !d.Contains(keep, 5) || d.Contains(keep, 3) {
+		t.Errorf("unexpected changed lines for keep.go: %v", d.Lines[keep])
+	}
+
+	if _, err := Changed(dir, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
@@ -2,6 +2,10 @@ package loader
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -9,17 +13,40 @@ import (
 // Result holds the loaded packages with type information.
 type Result struct {
 	Packages []*packages.Package
+	// SkippedTestFiles counts the _test.go files in the loaded packages'
+	// directories that Load left out. LoadWithTests leaves out none.
+	SkippedTestFiles int
 }
 
-// Load loads all Go packages in the given directory with full type information.
-func Load(dir string) (*Result, error) {
+// Load loads Go packages with full type information. Patterns are
+// resolved relative to dir the way the go command resolves them (import
+// paths, relative paths, and "..." wildcards); with no patterns, every
+// package under dir is loaded.
+func Load(dir string, patterns ...string) (*Result, error) {
+	return load(dir, false, patterns)
+}
+
+// LoadWithTests is Load including _test.go files. Each package is returned
+// once: its test variant replaces the plain package, and the generated
+// test main packages are dropped.
+func LoadWithTests(dir string, patterns ...string) (*Result, error) {
+	return load(dir, true, patterns)
+}
+
+func load(dir string, tests bool, patterns []string) (*Result, error) {
 	cfg := &packages.Config{
 		Mode: packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo |
-			packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps,
-		Dir: dir,
+			packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+			packages.NeedModule,
+		Dir:   dir,
+		Tests: tests,
 	}
 
-	pkgs, err := packages.Load(cfg, "./...")
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
 		return nil, fmt.Errorf("loading packages: %w", err)
 	}
@@ -35,5 +62,86 @@ func Load(dir string) (*Result, error) {
 		return nil, fmt.Errorf("package errors: %v", errs[0])
 	}
 
-	return &Result{Packages: pkgs}, nil
+	// Files are scanned in package order, so a run reports them the same
+	// way each time
+	slices.SortFunc(pkgs, func(a, b *packages.Package) int { return strings.Compare(a.ID, b.ID) })
+	if tests {
+		return &Result{Packages: dedupeTestVariants(pkgs)}, nil
+	}
+	return &Result{Packages: pkgs, SkippedTestFiles: countTestFiles(pkgs)}, nil
+}
+
+// countTestFiles counts the _test.go files in the directories of pkgs.
+func countTestFiles(pkgs []*packages.Package) int {
+	seen := map[string]bool{}
+	n := 0
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		dir := filepath.Dir(pkg.GoFiles[0])
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), "_test.go") {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Vendored returns the packages of the loaded import graph that come from a
+// vendor directory of a loaded package's module. Package patterns such as
+// "./..." never match vendored packages, so they are only ever loaded as
+// dependencies, with the same syntax and type information.
+func (r *Result) Vendored() []*packages.Package {
+	var vendors []string
+	for _, pkg := range r.Packages {
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			vendors = append(vendors, filepath.Join(pkg.Module.Dir, "vendor")+string(filepath.Separator))
+		}
+	}
+	var out []*packages.Package
+	packages.Visit(r.Packages, nil, func(pkg *packages.Package) {
+		if len(pkg.GoFiles) == 0 {
+			return
+		}
+		for _, v := range vendors {
+			if strings.HasPrefix(pkg.GoFiles[0], v) {
+				out = append(out, pkg)
+				return
+			}
+		}
+	})
+	return out
+}
+
+// dedupeTestVariants keeps one copy of every source file. A package with
+// in-package tests comes back twice, "p" and "p [p.test]", where the test
+// variant holds all of p's files; "p.test" is the generated test main.
+func dedupeTestVariants(pkgs []*packages.Package) []*packages.Package {
+	hasVariant := map[string]bool{}
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath+" ["+pkg.PkgPath+".test]" {
+			hasVariant[pkg.PkgPath] = true
+		}
+	}
+	var out []*packages.Package
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		if pkg.ID == pkg.PkgPath && hasVariant[pkg.PkgPath] {
+			continue
+		}
+		out = append(out, pkg)
+	}
+	return out
 }
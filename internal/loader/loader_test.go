@@ -80,3 +80,30 @@ type User struct {
 		t.Fatalf("expected at least 2 packages, got %d", len(result.Packages))
 	}
 }
+
+func TestLoad_SkippedTestFiles(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go":            "package main\n\nfunc main() {}\n",
+		"main_test.go":       "package main\n",
+		"repo/repo.go":       "package repo\n",
+		"repo/a_test.go":     "package repo\n",
+		"repo/b_test.go":     "package repo_test\n",
+		"testdata/x_test.go": "package x\n",
+	})
+
+	result, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if result.SkippedTestFiles != 3 {
+		t.Errorf("expected 3 skipped test files, got %d", result.SkippedTestFiles)
+	}
+
+	result, err = LoadWithTests(dir)
+	if err != nil {
+		t.Fatalf("LoadWithTests: %v", err)
+	}
+	if result.SkippedTestFiles != 0 {
+		t.Errorf("expected no skipped test files with tests, got %d", result.SkippedTestFiles)
+	}
+}
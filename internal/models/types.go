@@ -1,17 +1,125 @@
 package models
 
+import "time"
+
+// PreloadResult is the outcome of verifying one relation path. Kind names
+// the rule behind a non-valid status so findings can be told apart:
+// "not-found", "case-mismatch", "empty-relation", "malformed-path",
+// "unresolved-model", "interface-field", "not-preloadable", "dynamic",
+// "has-many-depth", "duplicate-preload", "redundant-preload",
+// "not-allowlisted", "unknown-column", "column-name", "scan-projection".
 type PreloadResult struct {
 	File     string `json:"file"`
 	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"` // 1-based byte column of the relation argument
 	Relation string `json:"relation"`
 	Model    string `json:"model"`
-	Status   string `json:"status"` // "valid", "error", "skipped"
+	Status   string `json:"status"` // "valid", "error", "warning", "info", "dynamic", "unknown", "skipped", "suppressed"
+	Kind     string `json:"kind,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Variable is the query's destination as written, without a leading &
+	// ("orders" for Find(&orders), "resp.Items").
+	Variable string `json:"variable,omitempty"`
+	// Source is how the preload reaches its query: "chain" (the same call
+	// chain), "variable" (a query variable) or "field" (an option field).
+	Source string `json:"source,omitempty"`
+	// Func is the function declaring the query: "List", or "Repo.List"
+	// for a method.
+	Func string `json:"func,omitempty"`
+	// PreloadExpr is the preload call as written, without its receiver
+	// (`Preload("Items", "active = ?", true)`); empty for option fields.
+	PreloadExpr string `json:"preload_expr,omitempty"`
+	// Explain traces how the model was resolved: the query call, how the
+	// preload reaches it, and the type the model came from. Set only when
+	// explaining (--explain).
+	Explain string `json:"explain,omitempty"`
+	// Suggestion lists the closest field names for the segment that wasn't
+	// found (ties alphabetical, at most three) for "not-found", the path
+	// with exact field casing for "case-mismatch", the path with Go field
+	// names for "column-name", or the closest column names for
+	// "unknown-column".
+	Suggestion []string `json:"suggestion,omitempty"`
+	// RelationType is the kind of relation the path's last segment is:
+	// "has_one", "has_many", "belongs_to" or "many2many"; TargetModel is
+	// the model it loads ("models.User"). Both are set when the path
+	// resolved to a struct.
+	RelationType string `json:"relation_type,omitempty"`
+	TargetModel  string `json:"target_model,omitempty"`
+	// FailedSegment is the 0-based index of the dotted path's segment the
+	// finding is about ("not-found", "case-mismatch", "column-name",
+	// "malformed-path", "not-preloadable", "interface-field"); nil for
+	// other results.
+	FailedSegment *int `json:"failed_segment,omitempty"`
 }
 
+// AnalysisResult is a run's summary and the results to display. The counts
+// and Accuracy and Coverage always cover every result of the run, whatever display
+// filter (-e, -V) narrowed Results; Displayed is len(Results).
+// SkippedTestFiles counts the _test.go files that were not analyzed.
+// MaxErrors and Verdict ("pass" or "fail") are set by the gpc command from
+// --max-errors and --fail-on; Verdict is empty otherwise. Stats is set by
+// the gpc command under --stats, and Meta describes the run. OnlyFiles,
+// OnlyRelations and OnlyModels hold the --only-files, --only-relation and
+// --only-model patterns of a filtered view, whose counts cover only the
+// results they match.
 type AnalysisResult struct {
-	Total   int              `json:"total"`
-	Valid   int              `json:"valid"`
-	Errors  int              `json:"errors"`
-	Skipped int              `json:"skipped"`
-	Results []PreloadResult  `json:"results"`
+	Total            int             `json:"total"`
+	Valid            int             `json:"valid"`
+	Errors           int             `json:"errors"`
+	Warnings         int             `json:"warnings"`
+	Info             int             `json:"info"`
+	Dynamic          int             `json:"dynamic"`
+	Unknown          int             `json:"unknown"`
+	Skipped          int             `json:"skipped"`
+	Suppressed       int             `json:"suppressed"`
+	Accuracy         float64         `json:"accuracy"`
+	Coverage         float64         `json:"coverage"`
+	Displayed        int             `json:"displayed"`
+	SkippedTestFiles int             `json:"skipped_test_files,omitempty"`
+	MaxErrors        int             `json:"max_errors"`
+	Verdict          string          `json:"verdict,omitempty"`
+	OnlyFiles        []string        `json:"only_files,omitempty"`
+	OnlyRelations    []string        `json:"only_relations,omitempty"`
+	OnlyModels       []string        `json:"only_models,omitempty"`
+	Stats            *Stats          `json:"stats,omitempty"`
+	Meta             *Meta           `json:"meta,omitempty"`
+	Results          []PreloadResult `json:"results"`
+}
+
+// Meta describes the run that produced an AnalysisResult, so a results
+// file can be audited: when it ran and for how long, on which targets, how
+// many Go files preloads were collected from and how many named struct
+// types the loaded packages declare. Version (the gpc build), Options
+// (every flag's effective value that shapes the analysis, config file and
+// --strict included) and ConfigHash (their SHA-256) are set by the gpc
+// command.
+type Meta struct {
+	Version    string            `json:"version,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	DurationMS int64             `json:"duration_ms"`
+	Targets    []string          `json:"targets"`
+	Files      int               `json:"files"`
+	Structs    int               `json:"structs"`
+	ConfigHash string            `json:"config_hash,omitempty"`
+	Options    map[string]string `json:"options,omitempty"`
+}
+
+// Stats describes what a run covered, over all of its results: the files
+// and distinct models with preloads, how many preloads were verified
+// (valid or error, as Coverage counts) or had no resolvable model, the preloads per Source, and the models with
+// the most preloads (ties by name).
+type Stats struct {
+	Files      int            `json:"files"`
+	Models     int            `json:"models"`
+	Preloads   int            `json:"preloads"`
+	Verified   int            `json:"verified"`
+	Unresolved int            `json:"unresolved"`
+	Sources    map[string]int `json:"sources"`
+	TopModels  []ModelCount   `json:"top_models"`
+}
+
+// ModelCount is a model and its number of preloads.
+type ModelCount struct {
+	Model    string `json:"model"`
+	Preloads int    `json:"preloads"`
 }
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"fmt"
+	"os"
+)
+
+// UseColor reports whether console output should be colorized under mode
+// ("auto", "always" or "never"). In auto mode, color is used only when f is
+// a terminal and NO_COLOR is unset or empty.
+func UseColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		return os.Getenv("NO_COLOR") == "" && isTerminal(f), nil
+	}
+	return false, fmt.Errorf("invalid --color %q (want auto, always, or never)", mode)
+}
+
+// isTerminal reports whether f is a terminal; tests replace it.
+var isTerminal = func(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ANSI SGR codes used by the console output.
+const (
+	bold   = "1"
+	red    = "31"
+	green  = "32"
+	yellow = "33"
+	cyan   = "36"
+)
+
+// paint wraps s in the given SGR code when color is on. An empty code
+// leaves s plain.
+func paint(color bool, code, s string) string {
+	if !color || code == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
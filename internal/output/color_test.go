@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestUseColor(t *testing.T) {
+	// A regular file is never a terminal, so auto stays off.
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		mode    string
+		noColor string
+		want    bool
+		wantErr bool
+	}{
+		{mode: "always", want: true},
+		{mode: "always", noColor: "1", want: true},
+		{mode: "never", want: false},
+		{mode: "auto", want: false},
+		{mode: "auto", noColor: "1", want: false},
+		{mode: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.noColor, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			got, err := UseColor(tt.mode, f)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseColor(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UseColor(%q) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUseColor_Terminal(t *testing.T) {
+	orig := isTerminal
+	isTerminal = func(*os.File) bool { return true }
+	t.Cleanup(func() { isTerminal = orig })
+
+	tests := []struct {
+		mode    string
+		noColor string
+		want    bool
+	}{
+		{mode: "auto", want: true},
+		{mode: "auto", noColor: "1", want: false},
+		{mode: "never", want: false},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		if got, _ := UseColor(tt.mode, os.Stdout); got != tt.want {
+			t.Errorf("UseColor(%q) on a terminal with NO_COLOR=%q = %v, want %v", tt.mode, tt.noColor, got, tt.want)
+		}
+	}
+}
+
+func TestWriteConsole_Color(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	results := []models.PreloadResult{
+		{File: file, Line: 10, Relation: "User", Status: "valid"},
+		{File: file, Line: 15, Relation: "Usr", Status: "error", Message: "Usr not found in db.Order"},
+		{File: file, Line: 20, Relation: "Items", Status: "unknown", Message: "model could not be resolved"},
+	}
+	result := report.Summarize(results)
+
+	var plain, colored bytes.Buffer
+	writeConsole(&plain, result, Style{})
+	writeConsole(&colored, result, Style{Color: true})
+
+	wantPlain := "order.go:15: Usr not found in db.Order\n" +
+		"order.go:20: Items not verified: model could not be resolved\n"
+	if got := plain.String(); got != wantPlain {
+		t.Errorf("plain output:\n%q\nwant:\n%q", got, wantPlain)
+	}
+	wantColored := "\x1b[1morder.go:15:\x1b[0m \x1b[31mUsr not found in db.Order\x1b[0m\n" +
+		"\x1b[1morder.go:20:\x1b[0m \x1b[33mItems not verified: model could not be resolved\x1b[0m\n"
+	if got := colored.String(); got != wantColored {
+		t.Errorf("colored output:\n%q\nwant:\n%q", got, wantColored)
+	}
+}
+
+func TestWriteSummary_Color(t *testing.T) {
+	result := report.Summarize([]models.PreloadResult{
+		{Status: "valid"},
+		{Status: "unknown"},
+		{Status: "skipped"},
+	})
+
+	var out, errOut bytes.Buffer
+	writeSummary(&out, &errOut, result, Style{Color: true})
+	want := "3 preload(s) checked, \x1b[32m1 valid\x1b[0m, \x1b[33m1 unknown\x1b[0m, 1 skipped; accuracy 100.0%, coverage 33.3%\n"
+	if got := out.String(); got != want {
+		t.Errorf("summary:\n%q\nwant:\n%q", got, want)
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("unexpected stderr output %q", errOut.String())
+	}
+}
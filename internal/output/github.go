@@ -0,0 +1,52 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// WriteGitHubOutput prints results as GitHub Actions workflow commands, so
+// errors and unverifiable preloads show up as annotations on the diff,
+// followed by the same summary as the console output. Unverified preloads
+// are errors under style.FailUnknown; style.Color is ignored.
+func WriteGitHubOutput(result *models.AnalysisResult, style Style) {
+	style.Color = false
+	writeGitHub(os.Stdout, result, style)
+	writeSummary(os.Stdout, os.Stderr, result, style)
+}
+
+func writeGitHub(w io.Writer, result *models.AnalysisResult, style Style) {
+	unknownLevel := "warning"
+	if style.FailUnknown {
+		unknownLevel = "error"
+	}
+	for _, r := range result.Results {
+		var level, msg string
+		switch r.Status {
+		case "error":
+			level, msg = "error", "invalid preload: "+r.Message
+		case "warning":
+			level, msg = "warning", r.Message
+		case "unknown":
+			level, msg = unknownLevel, fmt.Sprintf("%s not verified: %s", r.Relation, r.Message)
+		default:
+			continue
+		}
+		loc := fmt.Sprintf("file=%s,line=%d", propertyEscaper.Replace(shortenPath(r.File)), r.Line)
+		if r.Column > 0 {
+			loc += fmt.Sprintf(",col=%d", r.Column)
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", level, loc, dataEscaper.Replace(msg))
+	}
+}
+
+// dataEscaper and propertyEscaper escape workflow command messages and
+// property values, per the GitHub Actions toolkit.
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
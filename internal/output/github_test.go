@@ -0,0 +1,37 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteGitHub(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "repo", "order.go")
+	results := []models.PreloadResult{
+		{File: file, Line: 10, Relation: "User", Status: "valid"},
+		{File: file, Line: 15, Column: 14, Relation: "Usr", Status: "error", Message: `Usr not found in db.Order (did you mean "User"?)`},
+		{File: file, Line: 20, Relation: "Items", Status: "unknown", Message: "model could not be resolved"},
+		{File: file, Line: 25, Relation: "(dynamic)", Status: "dynamic"},
+		{File: filepath.Join(cwd, "a,b:c.go"), Line: 30, Relation: "X", Status: "error", Message: "100% wrong\r\nsecond line"},
+	}
+
+	var buf bytes.Buffer
+	writeGitHub(&buf, report.Summarize(results), Style{})
+
+	want := `::error file=repo/order.go,line=15,col=14::invalid preload: Usr not found in db.Order (did you mean "User"?)
+::warning file=repo/order.go,line=20::Items not verified: model could not be resolved
+::error file=a%2Cb%3Ac.go,line=30::invalid preload: 100%25 wrong%0D%0Asecond line
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected annotations:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+// codeQualityLocation has either Lines or, when the column is known,
+// Positions.
+type codeQualityLocation struct {
+	Path      string                `json:"path"`
+	Lines     *codeQualityLines     `json:"lines,omitempty"`
+	Positions *codeQualityPositions `json:"positions,omitempty"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+type codeQualityPositions struct {
+	Begin codeQualityPosition `json:"begin"`
+}
+
+type codeQualityPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// codeQualitySeverity maps result statuses to GitLab Code Quality
+// severities; other statuses are left out of the report.
+var codeQualitySeverity = map[string]string{
+	"error":   "major",
+	"unknown": "minor",
+	"warning": "minor",
+	"info":    "info",
+}
+
+// WriteGitLabOutput writes results as a GitLab Code Quality report, so
+// merge requests show findings inline. Each issue's fingerprint hashes the
+// file, relation, model and whitespace-normalized source line rather than
+// the line number, so edits elsewhere in the file don't churn the report.
+func WriteGitLabOutput(result *models.AnalysisResult, outputFile string) error {
+	data, err := json.MarshalIndent(codeQualityReport(result), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal gitlab: %w", err)
+	}
+	return writeFile(outputFile, append(data, '\n'))
+}
+
+func codeQualityReport(result *models.AnalysisResult) []codeQualityIssue {
+	issues := []codeQualityIssue{}
+	src := sources{}
+	seen := map[string]int{}
+	for _, r := range sortedResults(result.Results) {
+		severity, ok := codeQualitySeverity[r.Status]
+		if !ok {
+			continue
+		}
+		file := shortenPath(r.File)
+		content := strings.Join(strings.Fields(src.line(r.File, r.Line)), " ")
+
+		// Identical preloads on identical lines are told apart by order
+		key := strings.Join([]string{file, r.Relation, r.Model, content}, "\x00")
+		n := seen[key]
+		seen[key]++
+		if n > 0 {
+			key += fmt.Sprintf("\x00%d", n)
+		}
+		sum := sha256.Sum256([]byte(key))
+
+		checkName := "gpc"
+		if r.Kind != "" {
+			checkName += "/" + r.Kind
+		}
+		loc := codeQualityLocation{Path: file, Lines: &codeQualityLines{Begin: r.Line}}
+		if r.Column > 0 {
+			loc = codeQualityLocation{Path: file, Positions: &codeQualityPositions{Begin: codeQualityPosition{Line: r.Line, Column: r.Column}}}
+		}
+		issues = append(issues, codeQualityIssue{
+			Description: findingMessage(r),
+			CheckName:   checkName,
+			Fingerprint: hex.EncodeToString(sum[:16]),
+			Severity:    severity,
+			Location:    loc,
+		})
+	}
+	return issues
+}
+
+// findingMessage is the one-line message of a finding in report formats.
+func findingMessage(r models.PreloadResult) string {
+	if r.Status == "unknown" {
+		return r.Relation + " not verified: " + r.Message
+	}
+	return r.Message
+}
+
+// sources caches the lines of source files that reports quote, by path. A
+// file that can't be read has no lines.
+type sources map[string][]string
+
+// lines returns the lines of file, reading it on first use.
+func (s sources) lines(file string) []string {
+	lines, ok := s[file]
+	if !ok {
+		if data, err := os.ReadFile(file); err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		}
+		s[file] = lines
+	}
+	return lines
+}
+
+// line returns the 1-based line n of file, or "" when there is none.
+func (s sources) line(file string, n int) string {
+	lines := s.lines(file)
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
+}
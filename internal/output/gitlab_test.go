@@ -0,0 +1,78 @@
+package output
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestWriteGitLabOutput(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "testdata", "gitlab", "order.go")
+	results := []models.PreloadResult{
+		{File: file, Line: 7, Relation: "User", Model: "repo.Order", Status: "valid"},
+		{File: file, Line: 8, Column: 13, Relation: "Usr", Model: "repo.Order", Status: "error", Kind: "not-found", Message: `Usr not found in repo.Order (did you mean "User"?)`},
+		{File: file, Line: 9, Relation: "Items", Model: "Unknown", Status: "unknown", Kind: "unresolved-model", Message: "model could not be resolved"},
+		{File: file, Line: 10, Relation: "User", Model: "repo.Order", Status: "warning", Kind: "duplicate-preload", Message: "duplicate preload of User (lines 10 and 10)"},
+		{File: file, Line: 11, Relation: "Usr", Model: "repo.Order", Status: "error", Kind: "not-found", Message: `Usr not found in repo.Order (did you mean "User"?)`},
+		{File: file, Line: 12, Relation: "(dynamic)", Status: "dynamic"},
+	}
+
+	path := filepath.Join(t.TempDir(), "gl-code-quality-report.json")
+	if err := WriteGitLabOutput(report.Summarize(results), path); err != nil {
+		t.Fatalf("WriteGitLabOutput: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "gitlab.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("report differs from %s (rerun with -update to accept):\n%s", golden, got)
+	}
+}
+
+func TestWriteGitLabOutput_StableFingerprint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "order.go")
+	fingerprint := func(src string, line int) string {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		issues := codeQualityReport(report.Summarize([]models.PreloadResult{
+			{File: file, Line: line, Relation: "Usr", Model: "repo.Order", Status: "error", Message: "Usr not found"},
+		}))
+		if len(issues) != 1 {
+			t.Fatalf("expected 1 issue, got %d", len(issues))
+		}
+		return issues[0].Fingerprint
+	}
+
+	before := fingerprint("package repo\n\n\tdb.Preload(\"Usr\").Find(&orders)\n", 3)
+	moved := fingerprint("package repo\n\n// a new comment\n\n  db.Preload(\"Usr\").Find(&orders)  \n", 5)
+	changed := fingerprint("package repo\n\n\tdb.Preload(\"Usr\").First(&orders)\n", 3)
+	if before != moved {
+		t.Errorf("fingerprint changed when the line moved: %s vs %s", before, moved)
+	}
+	if before == changed {
+		t.Error("expected a different fingerprint for a different line")
+	}
+}
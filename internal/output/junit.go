@@ -0,0 +1,88 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitOutput writes results as JUnit XML: one testsuite per file and
+// one testcase per relation path, named relation@line. Errors are failures;
+// results that weren't verified (unknown, dynamic, skipped, suppressed) are
+// skipped test cases.
+func WriteJUnitOutput(result *models.AnalysisResult, outputFile string) error {
+	data, err := xml.MarshalIndent(junitReport(result), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal junit: %w", err)
+	}
+	return writeFile(outputFile, append([]byte(xml.Header), append(data, '\n')...))
+}
+
+func junitReport(result *models.AnalysisResult) junitTestSuites {
+	report := junitTestSuites{Name: "gpc"}
+	index := map[string]int{}
+	for _, r := range result.Results {
+		file := shortenPath(r.File)
+		i, ok := index[file]
+		if !ok {
+			i = len(report.Suites)
+			index[file] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: file})
+		}
+		suite := &report.Suites[i]
+
+		tc := junitTestCase{Name: r.Relation + "@" + strconv.Itoa(r.Line), Classname: file}
+		switch r.Status {
+		case "error":
+			tc.Failure = &junitMessage{Message: r.Message, Type: r.Kind, Text: fmt.Sprintf("%s:%d: %s", file, r.Line, r.Message)}
+			suite.Failures++
+		case "unknown", "dynamic", "skipped", "suppressed":
+			msg := r.Message
+			if msg == "" {
+				msg = r.Status
+			}
+			tc.Skipped = &junitMessage{Message: msg}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	for _, s := range report.Suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Skipped += s.Skipped
+	}
+	return report
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteJUnitOutput(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := filepath.Join(cwd, "repo", "order.go")
+	user := filepath.Join(cwd, "repo", "user.go")
+	results := []models.PreloadResult{
+		{File: order, Line: 10, Relation: "User", Status: "valid"},
+		{File: order, Line: 15, Relation: "Usr", Status: "error", Kind: "not-found", Message: "Usr not found in db.Order"},
+		{File: user, Line: 20, Relation: "Items", Status: "unknown", Message: "model could not be resolved"},
+	}
+
+	path := filepath.Join(t.TempDir(), "gpc.xml")
+	if err := WriteJUnitOutput(report.Summarize(results), path); err != nil {
+		t.Fatalf("WriteJUnitOutput: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Error("expected an XML declaration")
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Tests != 3 || got.Failures != 1 || got.Skipped != 1 {
+		t.Errorf("expected 3 tests, 1 failure, 1 skipped; got %d/%d/%d", got.Tests, got.Failures, got.Skipped)
+	}
+	if len(got.Suites) != 2 || got.Suites[0].Name != "repo/order.go" || got.Suites[1].Name != "repo/user.go" {
+		t.Fatalf("expected one suite per file, got %+v", got.Suites)
+	}
+
+	cases := got.Suites[0].Cases
+	if len(cases) != 2 || cases[0].Name != "User@10" || cases[1].Name != "Usr@15" {
+		t.Fatalf("unexpected test cases %+v", cases)
+	}
+	if cases[0].Failure != nil || cases[0].Skipped != nil {
+		t.Error("valid result should be a passing test case")
+	}
+	if f := cases[1].Failure; f == nil || f.Message != "Usr not found in db.Order" || f.Type != "not-found" {
+		t.Errorf("expected a not-found failure, got %+v", f)
+	}
+	if s := got.Suites[1].Cases[0].Skipped; s == nil || s.Message != "model could not be resolved" {
+		t.Errorf("expected unknown to be skipped, got %+v", s)
+	}
+}
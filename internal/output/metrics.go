@@ -0,0 +1,44 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+// metric is one gauge family in the metrics file.
+type metric struct {
+	name, help string
+	value      func(*models.AnalysisResult) float64
+}
+
+var metrics = []metric{
+	{"gpc_preloads_total", "Preload relation paths checked.", func(r *models.AnalysisResult) float64 { return float64(r.Total) }},
+	{"gpc_preloads_errors", "Preload relation paths that failed verification.", func(r *models.AnalysisResult) float64 { return float64(r.Errors) }},
+	{"gpc_preloads_unknown", "Preload relation paths that could not be verified.", func(r *models.AnalysisResult) float64 { return float64(r.Unknown) }},
+	{"gpc_accuracy", "Share of verified preload relation paths that are valid.", report.Accuracy},
+	{"gpc_coverage", "Share of preload relation paths that were verified valid or invalid.", report.Coverage},
+}
+
+// labelEscaper escapes a label value for the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics writes per-directory gauges in the Prometheus text
+// exposition format, for node-exporter's textfile collector.
+func WriteMetrics(dirs []report.DirSummary, metricsFile string) error {
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", m.name)
+		for _, d := range dirs {
+			fmt.Fprintf(&buf, "%s{dir=\"%s\"} %s\n", m.name, labelEscaper.Replace(d.Dir),
+				strconv.FormatFloat(m.value(d.Result), 'g', -1, 64))
+		}
+	}
+
+	return writeFile(metricsFile, buf.Bytes())
+}
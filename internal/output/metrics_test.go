@@ -0,0 +1,73 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	results := []models.PreloadResult{
+		{File: "/src/app/internal/repo/order.go", Status: "valid"},
+		{File: "/src/app/internal/repo/order.go", Status: "valid"},
+		{File: "/src/app/internal/repo/order.go", Status: "valid"},
+		{File: "/src/app/internal/api/user.go", Status: "error"},
+		{File: "/src/app/cmd/main.go", Status: "unknown"},
+		{File: "/src/app/cmd/main.go", Status: "dynamic"},
+	}
+	path := filepath.Join(t.TempDir(), "gpc.prom")
+	if err := WriteMetrics(report.ByDirectory(results, "/src/app"), path); err != nil {
+		t.Fatalf("WriteMetrics: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open metrics: %v", err)
+	}
+	defer f.Close()
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(f)
+	if err != nil {
+		t.Fatalf("parse metrics: %v", err)
+	}
+
+	want := map[string]map[string]float64{
+		"gpc_preloads_total":   {"cmd": 2, "internal": 4},
+		"gpc_preloads_errors":  {"cmd": 0, "internal": 1},
+		"gpc_preloads_unknown": {"cmd": 1, "internal": 0},
+		"gpc_accuracy":         {"cmd": 1, "internal": 0.75},
+		"gpc_coverage":         {"cmd": 0, "internal": 1},
+	}
+	if len(families) != len(want) {
+		t.Errorf("expected %d metric families, got %d", len(want), len(families))
+	}
+	for name, byDir := range want {
+		mf, ok := families[name]
+		if !ok {
+			t.Errorf("missing metric %s", name)
+			continue
+		}
+		if mf.GetType().String() != "GAUGE" {
+			t.Errorf("%s: expected gauge, got %s", name, mf.GetType())
+		}
+		if len(mf.GetMetric()) != len(byDir) {
+			t.Errorf("%s: expected %d series, got %d", name, len(byDir), len(mf.GetMetric()))
+		}
+		for _, m := range mf.GetMetric() {
+			labels := m.GetLabel()
+			if len(labels) != 1 || labels[0].GetName() != "dir" {
+				t.Errorf("%s: expected a single dir label, got %v", name, labels)
+				continue
+			}
+			dir := labels[0].GetValue()
+			if got := m.GetGauge().GetValue(); got != byDir[dir] {
+				t.Errorf("%s{dir=%q} = %v, want %v", name, dir, got, byDir[dir])
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// NDJSONWriter streams results as newline-delimited JSON: one
+// PreloadResult object per line, written as each file's results arrive,
+// then a summary object whose "type" is "summary". The first write error
+// is kept and stops later writes.
+type NDJSONWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewNDJSONWriter returns a writer streaming to w, which is written once
+// per result, so lines reach a pipe as soon as their file is done.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// ndjsonSummary is AnalysisResult without its results, tagged so readers
+// can tell it from a result line.
+type ndjsonSummary struct {
+	Type string `json:"type"`
+	*models.AnalysisResult
+	Results []models.PreloadResult `json:"results,omitempty"`
+}
+
+// WriteResults writes one line per result.
+func (n *NDJSONWriter) WriteResults(results []models.PreloadResult) {
+	for _, r := range results {
+		n.encode(r)
+	}
+}
+
+// WriteSummary ends the stream with result's counts and verdict, and
+// returns the first error of the stream.
+func (n *NDJSONWriter) WriteSummary(result *models.AnalysisResult) error {
+	n.encode(ndjsonSummary{Type: "summary", AnalysisResult: result})
+	return n.err
+}
+
+func (n *NDJSONWriter) encode(v any) {
+	if n.err != nil {
+		return
+	}
+	if err := n.enc.Encode(v); err != nil {
+		n.err = fmt.Errorf("write ndjson: %w", err)
+	}
+}
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	results := []models.PreloadResult{
+		{File: "a.go", Line: 10, Relation: "User", Model: "db.Order", Status: "valid"},
+		{File: "b.go", Line: 15, Relation: "Usr", Model: "db.Order", Status: "error", Kind: "not-found", Message: "Usr not found in db.Order"},
+	}
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	w.WriteResults(results[:1])
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Fatalf("expected the first file's line before the next file, got %d line(s)", got)
+	}
+	w.WriteResults(results[1:])
+	summary := report.Summarize(results)
+	summary.Verdict = "fail"
+	if err := w.WriteSummary(summary); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 results and a summary, got:\n%s", buf.String())
+	}
+	for i, r := range results {
+		var got models.PreloadResult
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil || got.Relation != r.Relation || got.Status != r.Status {
+			t.Errorf("line %d = %s, want %s %s (%v)", i+1, lines[i], r.Relation, r.Status, err)
+		}
+	}
+	var last map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last["type"] != "summary" || last["total"] != 2.0 || last["errors"] != 1.0 || last["verdict"] != "fail" {
+		t.Errorf("unexpected summary %s", lines[2])
+	}
+	if _, ok := last["results"]; ok {
+		t.Errorf("expected no results in the summary, got %s", lines[2])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestNDJSONWriter_Error(t *testing.T) {
+	w := NewNDJSONWriter(failingWriter{})
+	w.WriteResults([]models.PreloadResult{{Relation: "User"}})
+	err := w.WriteSummary(report.Summarize(nil))
+	if err == nil || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("expected the write error, got %v", err)
+	}
+}
@@ -1,94 +1,357 @@
 package output
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
 )
 
-func WriteStructuredOutput(results []models.PreloadResult, outputFile string, validationOnly, errorsOnly bool) error {
-	filtered := filterResults(results, validationOnly, errorsOnly)
-	stats := computeStats(filtered)
-
-	analysisResult := models.AnalysisResult{
-		Total:   stats.total,
-		Valid:   stats.valid,
-		Errors:  stats.errors,
-		Skipped: stats.skipped,
-		Results: filtered,
-	}
-
-	data, err := json.MarshalIndent(analysisResult, "", "  ")
+// WriteStructuredOutput writes result as JSON, with results sorted by file,
+// line, column and relation so the same input always produces the same
+// bytes.
+func WriteStructuredOutput(result *models.AnalysisResult, outputFile string) error {
+	sorted := *result
+	sorted.Results = sortedResults(result.Results)
+	data, err := json.MarshalIndent(&sorted, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal json: %w", err)
 	}
-	return os.WriteFile(outputFile, data, 0644)
+	return writeFile(outputFile, data)
+}
+
+// sortedResults returns a copy of results sorted by report.Compare.
+func sortedResults(results []models.PreloadResult) []models.PreloadResult {
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, report.Compare)
+	return sorted
+}
+
+// Style selects how the console and GitHub writers present results.
+type Style struct {
+	// ErrorsOnly leaves out the counts line of a run without failures.
+	ErrorsOnly bool
+	// Color paints console output with ANSI colors.
+	Color bool
+	// FailUnknown presents unverified preloads as failures, the way
+	// --fail-on=unknown counts them. Their status is unchanged.
+	FailUnknown bool
+	// MaxErrors is the --max-errors threshold: a run with at most this many
+	// failures passes, and its summary says so.
+	MaxErrors int
+	// Explain follows each result with its model resolution trace
+	// (PreloadResult.Explain), valid results included.
+	Explain bool
+	// SummaryOnly prints the per-status counts even when there are
+	// failures, for --summary-only.
+	SummaryOnly bool
+	// Context, when positive, prints this many source lines before and
+	// after each error, numbered, instead of the error's line alone.
+	Context int
+	// ShowScope follows each printed result with the function it is in
+	// and its preload call (PreloadResult.Func and PreloadExpr).
+	ShowScope bool
+}
+
+func WriteConsoleOutput(result *models.AnalysisResult, style Style) {
+	writeConsole(os.Stderr, result, style)
+	writeSummary(os.Stdout, os.Stderr, result, style)
+	if result.Stats != nil {
+		writeStats(os.Stdout, result.Stats)
+	}
 }
 
-func WriteConsoleOutput(results []models.PreloadResult, validationOnly, errorsOnly bool) {
-	filtered := filterResults(results, validationOnly, errorsOnly)
-	stats := computeStats(filtered)
+// writeStats prints the --stats block after the summary.
+func writeStats(w io.Writer, st *models.Stats) {
+	fmt.Fprintf(w, "\nstats: %d file(s), %d model(s), %d preload(s), %d verified, %d unresolved model\n",
+		st.Files, st.Models, st.Preloads, st.Verified, st.Unresolved)
+	var sources []string
+	for _, src := range []string{"chain", "variable", "field"} {
+		if n := st.Sources[src]; n > 0 {
+			sources = append(sources, fmt.Sprintf("%s %d", src, n))
+		}
+	}
+	if len(sources) > 0 {
+		fmt.Fprintf(w, "  sources: %s\n", strings.Join(sources, ", "))
+	}
+	if len(st.TopModels) > 0 {
+		top := make([]string, len(st.TopModels))
+		for i, m := range st.TopModels {
+			top[i] = fmt.Sprintf("%s %d", m.Model, m.Preloads)
+		}
+		fmt.Fprintf(w, "  top models: %s\n", strings.Join(top, ", "))
+	}
+}
 
-	for _, r := range filtered {
-		file := shortenPath(r.File)
+func writeConsole(w io.Writer, result *models.AnalysisResult, style Style) {
+	color := style.Color
+	src := sources{}
+	for _, r := range result.Results {
+		loc := paint(color, bold, fmt.Sprintf("%s:%d:", shortenPath(r.File), r.Line))
+		printed := true
 		switch r.Status {
 		case "error":
-			fmt.Fprintf(os.Stderr, "%s:%d: %s not found in %s\n", file, r.Line, r.Relation, r.Model)
-		case "skipped":
-			fmt.Fprintf(os.Stderr, "%s:%d: skipped (dynamic argument)\n", file, r.Line)
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, red, r.Message))
+			if style.Context > 0 {
+				writeContext(w, src.lines(r.File), r, style.Context, color)
+			} else if line, caret := snippet(src.line(r.File, r.Line), r.Column, r.Relation); caret != "" {
+				fmt.Fprintf(w, "    %s\n    %s\n", line, paint(color, red, caret))
+			}
+		case "warning":
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, yellow, "warning: "+r.Message))
+		case "info":
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, cyan, "info: "+r.Message))
+		case "dynamic":
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, yellow, "dynamic relation argument, not verified"))
+		case "unknown":
+			code := yellow
+			if style.FailUnknown {
+				code = red
+			}
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, code, r.Relation+" not verified: "+r.Message))
+		default:
+			printed = false
 		}
+		if style.ShowScope && printed {
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, cyan, scope(r)))
+		}
+		if style.Explain && r.Explain != "" {
+			fmt.Fprintf(w, "%s %s\n", loc, paint(color, cyan, "explain: "+r.Relation+": "+r.Explain))
+		}
+	}
+}
+
+// scope describes where r's preload is written, for --show-scope:
+// "in OrderRepo.List: Preload("Usr")".
+func scope(r models.PreloadResult) string {
+	in := "in package scope"
+	if r.Func != "" {
+		in = "in " + r.Func
+	}
+	if r.PreloadExpr == "" {
+		return in
 	}
+	return in + ": " + r.PreloadExpr
+}
 
-	if stats.errors > 0 {
-		fmt.Fprintf(os.Stderr, "\n%d error(s)\n", stats.errors)
-		os.Exit(2)
+// snippet returns line without its indentation and the caret line under
+// it (see carets). caret is "" when col is not on line.
+func snippet(line string, col int, relation string) (text, caret string) {
+	indent := indentWidth(line)
+	caret = carets(line, indent, col, relation)
+	if caret == "" {
+		return "", ""
 	}
+	return line[min(indent, col-1):], caret
+}
 
-	if !errorsOnly {
-		fmt.Fprintf(os.Stdout, "%d preload(s) checked, %d valid", stats.total, stats.valid)
-		if stats.skipped > 0 {
-			fmt.Fprintf(os.Stdout, ", %d skipped", stats.skipped)
+// writeContext prints the n lines before and after r's line, numbered and
+// with r's line marked by ">" and followed by its caret line, like
+// grep -n -C. Indentation common to the printed lines is trimmed.
+func writeContext(w io.Writer, lines []string, r models.PreloadResult, n int, color bool) {
+	if r.Line < 1 || r.Line > len(lines) {
+		return
+	}
+	first, last := max(1, r.Line-n), min(len(lines), r.Line+n)
+	indent := -1
+	for _, line := range lines[first-1 : last] {
+		if strings.TrimSpace(line) != "" && (indent < 0 || indentWidth(line) < indent) {
+			indent = indentWidth(line)
+		}
+	}
+	indent = max(indent, 0)
+	width := len(strconv.Itoa(last))
+	for i := first; i <= last; i++ {
+		line, mark := lines[i-1], " "
+		if i == r.Line {
+			mark = ">"
+		}
+		fmt.Fprintf(w, "%s %*d | %s\n", mark, width, i, line[min(indent, len(line)):])
+		if i != r.Line {
+			continue
+		}
+		if caret := carets(line, indent, r.Column, r.Relation); caret != "" {
+			fmt.Fprintf(w, "  %*s | %s\n", width, "", paint(color, red, caret))
 		}
-		fmt.Fprintln(os.Stdout)
 	}
 }
 
-func filterResults(results []models.PreloadResult, validationOnly, errorsOnly bool) []models.PreloadResult {
-	if !validationOnly && !errorsOnly {
-		return results
+// indentWidth returns the length in bytes of line's leading tabs and
+// spaces.
+func indentWidth(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// carets returns the line that marks the 1-based byte column col of line
+// when line is printed from byte from on: carets span relation's string
+// literal when it starts there, else a single one marks the column. Tabs
+// before the column are kept so both align however tabs are displayed. It
+// is "" when col is not on line.
+func carets(line string, from, col int, relation string) string {
+	if col < 1 || col > len(line) {
+		return ""
+	}
+	width := 1
+	if literalAt(line, col, relation) {
+		width = len(relation) + 2
 	}
-	var out []models.PreloadResult
-	for _, r := range results {
-		if errorsOnly && r.Status == "error" {
-			out = append(out, r)
-		} else if validationOnly && (r.Status == "valid" || r.Status == "error") {
-			out = append(out, r)
+	var b strings.Builder
+	for _, c := range line[min(from, col-1) : col-1] {
+		if c == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
 		}
 	}
-	return out
+	b.WriteString(strings.Repeat("^", utf8.RuneCountInString(line[col-1:col-1+width])))
+	return b.String()
 }
 
-type stats struct {
-	total, valid, errors, skipped int
+// writeSummary prints the closing line of a run: the failure counts to
+// errw when there are failures, otherwise the per-status counts to w
+// (unless style.ErrorsOnly). Under style.SummaryOnly the per-status counts,
+// errors included, precede the failure counts.
+func writeSummary(w, errw io.Writer, result *models.AnalysisResult, style Style) {
+	color := style.Color
+	var failures []string
+	count := result.Errors
+	if result.Errors > 0 {
+		failures = append(failures, fmt.Sprintf("%d error(s)", result.Errors))
+	}
+	if style.FailUnknown && result.Unknown > 0 {
+		failures = append(failures, fmt.Sprintf("%d unknown", result.Unknown))
+		count += result.Unknown
+	}
+	if !style.ErrorsOnly && (len(failures) == 0 || style.SummaryOnly) {
+		writeCounts(w, result, color)
+	}
+	if len(failures) > 0 {
+		line, code := strings.Join(failures, ", "), red
+		if style.MaxErrors > 0 {
+			line += fmt.Sprintf(" (threshold %d)", style.MaxErrors)
+			if count <= style.MaxErrors {
+				line, code = line+", passing", yellow
+			}
+		}
+		line += filteredView(result)
+		fmt.Fprintf(errw, "\n%s\n", paint(color, code, line))
+	}
 }
 
-func computeStats(results []models.PreloadResult) stats {
-	var s stats
-	s.total = len(results)
-	for _, r := range results {
-		switch r.Status {
-		case "valid":
-			s.valid++
-		case "error":
-			s.errors++
-		case "skipped":
-			s.skipped++
+// writeCounts prints the per-status counts line, with the accuracy and
+// coverage of a run that checked any preload.
+func writeCounts(w io.Writer, result *models.AnalysisResult, color bool) {
+	fmt.Fprintf(w, "%d preload(s) checked, %s", result.Total, paint(color, green, fmt.Sprintf("%d valid", result.Valid)))
+	counts := []struct {
+		n     int
+		label string
+		code  string
+	}{
+		{result.Errors, "error(s)", red},
+		{result.Warnings, "warning(s)", yellow},
+		{result.Info, "info", cyan},
+		{result.Dynamic, "dynamic", yellow},
+		{result.Unknown, "unknown", yellow},
+		{result.Skipped, "skipped", ""},
+		{result.Suppressed, "suppressed", ""},
+	}
+	for _, c := range counts {
+		if c.n == 0 {
+			continue
 		}
+		fmt.Fprintf(w, ", %s", paint(color, c.code, fmt.Sprintf("%d %s", c.n, c.label)))
+	}
+	if result.Total > 0 {
+		fmt.Fprintf(w, "; accuracy %.1f%%, coverage %.1f%%", 100*result.Accuracy, 100*result.Coverage)
+	}
+	if result.SkippedTestFiles > 0 {
+		fmt.Fprintf(w, " (%d test file(s) skipped; use --tests to include them)", result.SkippedTestFiles)
+	}
+	fmt.Fprintln(w, filteredView(result))
+}
+
+// filteredView notes that result's counts cover only what the
+// --only-files, --only-relation and --only-model patterns match, or is "".
+func filteredView(result *models.AnalysisResult) string {
+	var filters []string
+	for _, f := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"files", result.OnlyFiles},
+		{"relations", result.OnlyRelations},
+		{"models", result.OnlyModels},
+	} {
+		if len(f.patterns) > 0 {
+			filters = append(filters, f.name+" "+strings.Join(f.patterns, ", "))
+		}
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return " (filtered view: " + strings.Join(filters, "; ") + ")"
+}
+
+// Stdout is the output path ("-f -") that writes to standard output.
+const Stdout = "-"
+
+// writeFile replaces path with data through a temp file in the same
+// directory and a rename, so a reader never sees a partial file and a
+// failed write leaves any previous file untouched. Stdout writes data to
+// standard output.
+func writeFile(path string, data []byte) error {
+	return streamFile(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// streamFile is writeFile for output written piecewise by write.
+func streamFile(path string, write func(io.Writer) error) error {
+	if path == Stdout {
+		bw := bufio.NewWriter(os.Stdout)
+		err := write(bw)
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("write stdout: %w", err)
+		}
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gpc-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	bw := bufio.NewWriter(tmp)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
 	}
-	return s
+	return nil
 }
 
 func shortenPath(path string) string {
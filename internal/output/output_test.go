@@ -1,21 +1,28 @@
 package output
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
 )
 
 func TestWriteStructuredOutput(t *testing.T) {
 	results := []models.PreloadResult{
 		{File: "test.go", Line: 10, Relation: "User", Model: "Order", Status: "valid"},
-		{File: "test.go", Line: 15, Relation: "Invalid", Model: "Order", Status: "error"},
-		{File: "test.go", Line: 20, Relation: "(dynamic)", Model: "Order", Status: "skipped"},
+		{File: "test.go", Line: 15, Relation: "Invalid", Model: "Order", Status: "error", Message: "Invalid not found in Order"},
+		{File: "test.go", Line: 20, Relation: "(dynamic)", Model: "Order", Status: "dynamic"},
 	}
 
 	testFile := "test_output.json"
-	err := WriteStructuredOutput(results, testFile, false, false)
+	err := WriteStructuredOutput(report.Summarize(results), testFile)
 	if err != nil {
 		t.Fatalf("WriteStructuredOutput: %v", err)
 	}
@@ -26,7 +33,7 @@ func TestWriteStructuredOutput(t *testing.T) {
 		t.Fatalf("read output: %v", err)
 	}
 
-	for _, field := range []string{"total", "valid", "errors", "skipped", "results"} {
+	for _, field := range []string{"total", "valid", "errors", "dynamic", "unknown", "accuracy", "displayed", "results"} {
 		if !contains(string(content), field) {
 			t.Errorf("output missing field %q", field)
 		}
@@ -35,7 +42,7 @@ func TestWriteStructuredOutput(t *testing.T) {
 
 func TestWriteStructuredOutput_Empty(t *testing.T) {
 	testFile := "test_empty.json"
-	err := WriteStructuredOutput(nil, testFile, false, false)
+	err := WriteStructuredOutput(report.Summarize(nil), testFile)
 	if err != nil {
 		t.Fatalf("WriteStructuredOutput: %v", err)
 	}
@@ -45,11 +52,11 @@ func TestWriteStructuredOutput_Empty(t *testing.T) {
 func TestWriteStructuredOutput_ErrorsOnly(t *testing.T) {
 	results := []models.PreloadResult{
 		{File: "test.go", Line: 10, Relation: "User", Model: "Order", Status: "valid"},
-		{File: "test.go", Line: 15, Relation: "Bad", Model: "Order", Status: "error"},
+		{File: "test.go", Line: 15, Relation: "Bad", Model: "Order", Status: "error", Message: "Bad not found in Order"},
 	}
 
 	testFile := "test_errors_only.json"
-	err := WriteStructuredOutput(results, testFile, false, true)
+	err := WriteStructuredOutput(report.Build(results, false, true), testFile)
 	if err != nil {
 		t.Fatalf("WriteStructuredOutput: %v", err)
 	}
@@ -62,28 +69,32 @@ func TestWriteStructuredOutput_ErrorsOnly(t *testing.T) {
 	if contains(string(content), `"status": "valid"`) {
 		t.Error("errors-only output should not contain valid results")
 	}
+	// The summary still covers the valid result the filter hid
+	for _, field := range []string{`"total": 2`, `"valid": 1`, `"errors": 1`, `"accuracy": 0.5`, `"displayed": 1`} {
+		if !contains(string(content), field) {
+			t.Errorf("output missing %s", field)
+		}
+	}
 }
 
-func TestFilterResults(t *testing.T) {
-	results := []models.PreloadResult{
-		{Status: "valid"},
-		{Status: "error"},
-		{Status: "skipped"},
+func TestWriteStructuredOutput_Unwritable(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "missing", "out.json")
+	if err := WriteStructuredOutput(report.Summarize(nil), dest); err == nil {
+		t.Fatal("expected an error for a missing directory")
 	}
 
-	errOnly := filterResults(results, false, true)
-	if len(errOnly) != 1 || errOnly[0].Status != "error" {
-		t.Errorf("errors-only: expected 1 error, got %d", len(errOnly))
+	// Renaming over a non-empty directory fails after the temp file is written
+	dest = filepath.Join(dir, "taken")
+	if err := os.MkdirAll(filepath.Join(dest, "child"), 0755); err != nil {
+		t.Fatal(err)
 	}
-
-	validOnly := filterResults(results, true, false)
-	if len(validOnly) != 2 {
-		t.Errorf("validation-only: expected 2 (valid+error), got %d", len(validOnly))
+	if err := WriteStructuredOutput(report.Summarize(nil), dest); err == nil {
+		t.Fatal("expected an error when the destination is a directory")
 	}
-
-	all := filterResults(results, false, false)
-	if len(all) != 3 {
-		t.Errorf("unfiltered: expected 3, got %d", len(all))
+	leftovers, _ := filepath.Glob(filepath.Join(dir, ".gpc-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("expected no partial files, found %v", leftovers)
 	}
 }
 
@@ -95,3 +106,306 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestWriteStructuredOutput_Deterministic(t *testing.T) {
+	results := []models.PreloadResult{
+		{File: "b.go", Line: 3, Relation: "User", Status: "valid"},
+		{File: "a.go", Line: 9, Relation: "Items", Status: "valid"},
+		{File: "a.go", Line: 2, Relation: "Usr", Status: "error", Message: "Usr not found in Order"},
+		{File: "a.go", Line: 2, Relation: "Items", Status: "valid"},
+	}
+	reversed := slices.Clone(results)
+	slices.Reverse(reversed)
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.json"), filepath.Join(dir, "second.json")
+	if err := WriteStructuredOutput(report.Summarize(results), first); err != nil {
+		t.Fatalf("WriteStructuredOutput: %v", err)
+	}
+	if err := WriteStructuredOutput(report.Summarize(reversed), second); err != nil {
+		t.Fatalf("WriteStructuredOutput: %v", err)
+	}
+
+	a, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("expected identical output regardless of result order:\n%s\n---\n%s", a, b)
+	}
+
+	var got models.AnalysisResult
+	if err := json.Unmarshal(a, &got); err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, r := range got.Results {
+		order = append(order, fmt.Sprintf("%s:%d:%s", r.File, r.Line, r.Relation))
+	}
+	want := []string{"a.go:2:Items", "a.go:2:Usr", "a.go:9:Items", "b.go:3:User"}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected results sorted as %v, got %v", want, order)
+	}
+	if results[0].File != "b.go" {
+		t.Error("expected the caller's results to be left in place")
+	}
+}
+
+func TestWriteSummary_SkippedTestFiles(t *testing.T) {
+	result := report.Summarize([]models.PreloadResult{{Status: "valid"}})
+	result.SkippedTestFiles = 4
+
+	var out, errOut bytes.Buffer
+	writeSummary(&out, &errOut, result, Style{})
+	want := "1 preload(s) checked, 1 valid; accuracy 100.0%, coverage 100.0% (4 test file(s) skipped; use --tests to include them)\n"
+	if got := out.String(); got != want {
+		t.Errorf("summary:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestWriteConsole_FailUnknown(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := report.Summarize([]models.PreloadResult{
+		{File: filepath.Join(cwd, "order.go"), Line: 20, Relation: "Items", Status: "unknown", Message: "model could not be resolved"},
+		{Status: "valid"},
+	})
+	style := Style{Color: true, FailUnknown: true}
+
+	var console, out, errOut bytes.Buffer
+	writeConsole(&console, result, style)
+	if want := "\x1b[1morder.go:20:\x1b[0m \x1b[31mItems not verified: model could not be resolved\x1b[0m\n"; console.String() != want {
+		t.Errorf("console:\n%q\nwant:\n%q", console.String(), want)
+	}
+	writeSummary(&out, &errOut, result, style)
+	if want := "\n\x1b[31m1 unknown\x1b[0m\n"; errOut.String() != want || out.Len() != 0 {
+		t.Errorf("summary: stdout %q, stderr %q; want stderr %q", out.String(), errOut.String(), want)
+	}
+
+	var gh bytes.Buffer
+	writeGitHub(&gh, result, style)
+	if want := "::error file=order.go,line=20::Items not verified: model could not be resolved\n"; gh.String() != want {
+		t.Errorf("annotations:\n%q\nwant:\n%q", gh.String(), want)
+	}
+}
+
+func TestWriteConsole_Explain(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	trace := "Find(&orders) on line 12 (same chain): model repo.Order from type *[]Order"
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 10, Relation: "User", Status: "valid", Explain: trace},
+		{File: file, Line: 11, Relation: "Usr", Status: "error", Message: "Usr not found in repo.Order", Explain: trace},
+	})
+
+	var plain, explained bytes.Buffer
+	writeConsole(&plain, result, Style{})
+	writeConsole(&explained, result, Style{Explain: true})
+	if want := "order.go:11: Usr not found in repo.Order\n"; plain.String() != want {
+		t.Errorf("without Explain:\n%q\nwant:\n%q", plain.String(), want)
+	}
+	want := "order.go:10: explain: User: " + trace + "\n" +
+		"order.go:11: Usr not found in repo.Order\n" +
+		"order.go:11: explain: Usr: " + trace + "\n"
+	if explained.String() != want {
+		t.Errorf("with Explain:\n%q\nwant:\n%q", explained.String(), want)
+	}
+}
+
+func TestWriteConsole_ShowScope(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 10, Relation: "User", Status: "valid", Func: "OrderRepo.List", PreloadExpr: `Preload("User")`},
+		{File: file, Line: 11, Relation: "Usr", Status: "error", Message: "Usr not found in repo.Order", Func: "OrderRepo.List", PreloadExpr: `Preload("Usr", "active")`},
+		{File: file, Line: 20, Relation: "(dynamic)", Status: "dynamic", PreloadExpr: "Preload(rel)"},
+	})
+
+	var plain, scoped bytes.Buffer
+	writeConsole(&plain, result, Style{})
+	writeConsole(&scoped, result, Style{ShowScope: true})
+	if want := "order.go:11: Usr not found in repo.Order\norder.go:20: dynamic relation argument, not verified\n"; plain.String() != want {
+		t.Errorf("without ShowScope:\n%q\nwant:\n%q", plain.String(), want)
+	}
+	want := "order.go:11: Usr not found in repo.Order\n" +
+		"order.go:11: in OrderRepo.List: Preload(\"Usr\", \"active\")\n" +
+		"order.go:20: dynamic relation argument, not verified\n" +
+		"order.go:20: in package scope: Preload(rel)\n"
+	if scoped.String() != want {
+		t.Errorf("with ShowScope:\n%q\nwant:\n%q", scoped.String(), want)
+	}
+}
+
+func TestWriteConsole_Snippet(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "order.go")
+	src := "package repo\n\nfunc Load(db *gorm.DB) {\n\tdb.\n\t\tPreload(\"Usr\").\n\t\tFind(&orders)\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 5, Column: 11, Relation: "Usr", Status: "error", Message: "Usr not found in repo.Order"},
+		{File: file, Line: 5, Column: 11, Relation: "User", Status: "valid"},
+	})
+
+	var console bytes.Buffer
+	writeConsole(&console, result, Style{})
+	want := "Usr not found in repo.Order\n    Preload(\"Usr\").\n            ^^^^^\n"
+	if got := console.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("console:\n%q\nwant suffix:\n%q", got, want)
+	}
+}
+
+func TestWriteConsole_Context(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "order.go")
+	src := "package repo\n\nfunc Load(db *gorm.DB) {\n\tdb.\n\t\tPreload(\"Usr\").\n\t\tFind(&orders)\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 5, Column: 11, Relation: "Usr", Status: "error", Message: "Usr not found in repo.Order"},
+		{File: file, Line: 7, Column: 1, Relation: "Usr", Status: "error", Message: "Usr not found in repo.Order"},
+	})
+
+	var console bytes.Buffer
+	writeConsole(&console, result, Style{Context: 2})
+	want := []string{
+		"  3 | func Load(db *gorm.DB) {",
+		"  4 | \tdb.",
+		"> 5 | \t\tPreload(\"Usr\").",
+		"    | \t\t        ^^^^^",
+		"  6 | \t\tFind(&orders)",
+		"  7 | }",
+		"  5 | \t\tPreload(\"Usr\").",
+		"  6 | \t\tFind(&orders)",
+		"> 7 | }",
+		"    | ^",
+	}
+	got := strings.Split(strings.TrimSuffix(console.String(), "\n"), "\n")
+	got = slices.DeleteFunc(got, func(line string) bool { return strings.HasSuffix(line, ": Usr not found in repo.Order") })
+	if !slices.Equal(got, want) {
+		t.Errorf("console:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The common indentation of the printed lines is trimmed
+	console.Reset()
+	writeConsole(&console, &models.AnalysisResult{Results: result.Results[:1]}, Style{Context: 1})
+	if want := "  4 | db.\n> 5 | \tPreload(\"Usr\").\n    | \t        ^^^^^\n  6 | \tFind(&orders)\n"; !strings.HasSuffix(console.String(), want) {
+		t.Errorf("console:\n%q\nwant suffix:\n%q", console.String(), want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		line     string
+		col      int
+		relation string
+		text     string
+		caret    string
+	}{
+		{"\tdb.Preload(\"Usr\").Find(&o)", 13, "Usr", "db.Preload(\"Usr\").Find(&o)", "           ^^^^^"},
+		{"\tx := map[string]any{\"a\":\tPreload(`Usr`)}", 35, "Usr", "x := map[string]any{\"a\":\tPreload(`Usr`)}", "                        \t        ^^^^^"},
+		{"\tdb.Preload(rel)", 13, "Usr", "db.Preload(rel)", "           ^"},
+		{"\tdb.Preload(\"Émile\")", 13, "Émile", "db.Preload(\"Émile\")", "           ^^^^^^^"},
+		{"\tdb.Preload(\"Usr\")", 40, "Usr", "", ""},
+		{"", 1, "Usr", "", ""},
+	}
+	for _, tt := range tests {
+		text, caret := snippet(tt.line, tt.col, tt.relation)
+		if text != tt.text || caret != tt.caret {
+			t.Errorf("snippet(%q, %d) = %q, %q; want %q, %q", tt.line, tt.col, text, caret, tt.text, tt.caret)
+		}
+	}
+}
+
+func TestWriteSummary_MaxErrors(t *testing.T) {
+	result := report.Summarize([]models.PreloadResult{
+		{Status: "error"}, {Status: "error"}, {Status: "unknown"}, {Status: "valid"},
+	})
+	tests := []struct {
+		name  string
+		style Style
+		want  string
+	}{
+		{"no threshold", Style{}, "\n2 error(s)\n"},
+		{"within threshold", Style{MaxErrors: 3}, "\n2 error(s) (threshold 3), passing\n"},
+		{"unknowns count", Style{MaxErrors: 2, FailUnknown: true}, "\n2 error(s), 1 unknown (threshold 2)\n"},
+		{"colored passing", Style{MaxErrors: 2, Color: true}, "\n\x1b[33m2 error(s) (threshold 2), passing\x1b[0m\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			writeSummary(&out, &errOut, result, tt.style)
+			if errOut.String() != tt.want || out.Len() != 0 {
+				t.Errorf("stdout %q, stderr %q; want stderr %q", out.String(), errOut.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	var out bytes.Buffer
+	writeStats(&out, &models.Stats{
+		Files: 2, Models: 2, Preloads: 5, Verified: 4, Unresolved: 1,
+		Sources:   map[string]int{"chain": 4, "field": 1},
+		TopModels: []models.ModelCount{{Model: "db.User", Preloads: 3}, {Model: "db.Order", Preloads: 1}},
+	})
+	want := "\nstats: 2 file(s), 2 model(s), 5 preload(s), 4 verified, 1 unresolved model\n" +
+		"  sources: chain 4, field 1\n" +
+		"  top models: db.User 3, db.Order 1\n"
+	if got := out.String(); got != want {
+		t.Errorf("stats:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestWriteSummary_FilteredView(t *testing.T) {
+	result := report.Summarize([]models.PreloadResult{{Status: "valid"}})
+	result.OnlyFiles = []string{"handlers/**", "repo/*.go"}
+	result.OnlyModels = []string{"Invoice"}
+	var out, errOut bytes.Buffer
+	writeSummary(&out, &errOut, result, Style{})
+	if want := "1 preload(s) checked, 1 valid; accuracy 100.0%, coverage 100.0% (filtered view: files handlers/**, repo/*.go; models Invoice)\n"; out.String() != want {
+		t.Errorf("summary:\n%q\nwant:\n%q", out.String(), want)
+	}
+
+	result = report.Summarize([]models.PreloadResult{{Status: "error"}})
+	result.OnlyRelations = []string{"Items*"}
+	out.Reset()
+	writeSummary(&out, &errOut, result, Style{})
+	if want := "\n1 error(s) (filtered view: relations Items*)\n"; errOut.String() != want {
+		t.Errorf("failure line:\n%q\nwant:\n%q", errOut.String(), want)
+	}
+}
+
+func TestWriteSummary_SummaryOnly(t *testing.T) {
+	result := report.Summarize([]models.PreloadResult{{Status: "valid"}, {Status: "error"}, {Status: "dynamic"}})
+
+	tests := []struct {
+		style         Style
+		wantOut, want string
+	}{
+		{Style{}, "", "\n1 error(s)\n"},
+		{Style{SummaryOnly: true}, "3 preload(s) checked, 1 valid, 1 error(s), 1 dynamic; accuracy 50.0%, coverage 66.7%\n", "\n1 error(s)\n"},
+		{Style{SummaryOnly: true, ErrorsOnly: true}, "", "\n1 error(s)\n"},
+	}
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		writeSummary(&out, &errOut, result, tt.style)
+		if out.String() != tt.wantOut || errOut.String() != tt.want {
+			t.Errorf("%+v: stdout %q, stderr %q; want %q, %q", tt.style, out.String(), errOut.String(), tt.wantOut, tt.want)
+		}
+	}
+}
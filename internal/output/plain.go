@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// WritePlainOutput prints one path:line:col: message line per error and
+// unverified preload, for editor quickfix lists and CI log matchers, to
+// stdout when outputFile is empty. There is no header or summary, and col
+// is left out when the column is unknown.
+func WritePlainOutput(result *models.AnalysisResult, outputFile string) error {
+	if outputFile == "" {
+		outputFile = Stdout
+	}
+	return streamFile(outputFile, func(w io.Writer) error {
+		return writePlain(w, result)
+	})
+}
+
+func writePlain(w io.Writer, result *models.AnalysisResult) error {
+	for _, r := range sortedResults(result.Results) {
+		if r.Status != "error" && r.Status != "unknown" {
+			continue
+		}
+		loc := fmt.Sprintf("%s:%d", shortenPath(r.File), r.Line)
+		if r.Column > 0 {
+			loc += fmt.Sprintf(":%d", r.Column)
+		}
+		// Keep each finding on one line
+		msg := strings.Join(strings.Fields(findingMessage(r)), " ")
+		if _, err := fmt.Fprintf(w, "%s: %s\n", loc, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFileList writes files to w one per line, relative to the working
+// directory like result paths, for --list-files.
+func WriteFileList(w io.Writer, files []string) error {
+	for _, file := range files {
+		if _, err := fmt.Fprintln(w, shortenPath(file)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
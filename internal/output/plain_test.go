@@ -0,0 +1,35 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWritePlain(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 20, Relation: "Items", Status: "unknown", Message: "model could not be resolved"},
+		{File: file, Line: 10, Column: 13, Relation: "User", Status: "valid"},
+		{File: file, Line: 15, Column: 13, Relation: "Usr", Status: "error", Message: "Usr not found in db.Order\n(did you mean \"User\"?)"},
+		{File: file, Line: 25, Column: 13, Relation: "Items", Status: "warning", Message: "duplicate preload"},
+	})
+
+	var buf bytes.Buffer
+	if err := writePlain(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	want := "order.go:15:13: Usr not found in db.Order (did you mean \"User\"?)\n" +
+		"order.go:20: Items not verified: model could not be resolved\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Progress redraws a one-line status ("checked 120/800 files") on a
+// terminal while a run is in progress. Its methods do nothing on a nil
+// Progress, which NewProgress returns when f is not a terminal.
+type Progress struct {
+	w     io.Writer
+	stage string
+	last  time.Time
+	every time.Duration
+}
+
+// NewProgress returns a Progress drawing on f, or nil when f is not a
+// terminal, so redirected stderr and CI logs stay free of redraws.
+func NewProgress(f *os.File) *Progress {
+	if !isTerminal(f) {
+		return nil
+	}
+	return &Progress{w: f, every: 100 * time.Millisecond}
+}
+
+// Update draws the stage of a run (see gpc.Options.Progress). Within a
+// stage, file counts are redrawn at most every 100ms, except for the last
+// file.
+func (p *Progress) Update(stage string, done, total int) {
+	if p == nil {
+		return
+	}
+	if stage == p.stage && done < total && time.Since(p.last) < p.every {
+		return
+	}
+	p.stage = stage
+	switch stage {
+	case "load":
+		p.draw("gpc: loading packages...")
+	case "collect":
+		p.draw(fmt.Sprintf("gpc: checked %d/%d files", done, total))
+	}
+}
+
+// Done clears the status line.
+func (p *Progress) Done() {
+	if p == nil {
+		return
+	}
+	fmt.Fprint(p.w, "\r\x1b[K")
+}
+
+func (p *Progress) draw(line string) {
+	p.last = time.Now()
+	fmt.Fprint(p.w, "\r\x1b[K"+line)
+}
@@ -0,0 +1,42 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewProgress_NotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := NewProgress(f)
+	if p != nil {
+		t.Fatal("expected no progress on a regular file")
+	}
+	// A nil Progress is safe to use
+	p.Update("collect", 1, 2)
+	p.Done()
+}
+
+func TestProgress_Update(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Progress{w: &buf, every: time.Hour}
+	p.Update("load", 0, 0)
+	p.Update("collect", 1, 3)
+	p.Update("collect", 2, 3) // throttled
+	p.Update("collect", 3, 3) // the last file always draws
+	p.Done()
+
+	want := "\r\x1b[Kgpc: loading packages..." +
+		"\r\x1b[Kgpc: checked 1/3 files" +
+		"\r\x1b[Kgpc: checked 3/3 files" +
+		"\r\x1b[K"
+	if got := buf.String(); got != want {
+		t.Errorf("progress output:\n%q\nwant:\n%q", got, want)
+	}
+}
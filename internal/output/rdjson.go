@@ -0,0 +1,174 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// docsURL documents the finding kinds that rdjson codes link to.
+const docsURL = "https://github.com/your-moon/gpc#what-it-catches"
+
+type rdSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdResult struct {
+	Source      rdSource       `json:"source"`
+	Diagnostics []rdDiagnostic `json:"diagnostics"`
+}
+
+type rdDiagnostic struct {
+	Message     string         `json:"message"`
+	Location    rdLocation     `json:"location"`
+	Severity    string         `json:"severity"`
+	Source      *rdSource      `json:"source,omitempty"`
+	Code        rdCode         `json:"code"`
+	Suggestions []rdSuggestion `json:"suggestions,omitempty"`
+}
+
+type rdLocation struct {
+	Path  string  `json:"path"`
+	Range rdRange `json:"range"`
+}
+
+// rdRange is a span of bytes on one line; End is exclusive and may be
+// omitted.
+type rdRange struct {
+	Start rdPosition  `json:"start"`
+	End   *rdPosition `json:"end,omitempty"`
+}
+
+type rdPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type rdCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type rdSuggestion struct {
+	Range rdRange `json:"range"`
+	Text  string  `json:"text"`
+}
+
+// rdSeverity maps result statuses to Reviewdog Diagnostic Format
+// severities; other statuses are left out.
+var rdSeverity = map[string]string{
+	"error":   "ERROR",
+	"unknown": "WARNING",
+	"warning": "WARNING",
+	"info":    "INFO",
+}
+
+// WriteRDJSONOutput writes results in the Reviewdog Diagnostic Format, to
+// stdout when outputFile is empty. jsonLines selects rdjsonl, one
+// diagnostic per line, over a single rdjson object. Did-you-mean fixes of
+// a relation written as a string literal become suggestions.
+func WriteRDJSONOutput(result *models.AnalysisResult, outputFile string, jsonLines bool) error {
+	var buf bytes.Buffer
+	if err := writeRDJSON(&buf, result, jsonLines); err != nil {
+		return err
+	}
+	if outputFile == "" {
+		outputFile = Stdout
+	}
+	return writeFile(outputFile, buf.Bytes())
+}
+
+func writeRDJSON(w io.Writer, result *models.AnalysisResult, jsonLines bool) error {
+	source := rdSource{Name: "gpc", URL: "https://github.com/your-moon/gpc"}
+	diagnostics := rdDiagnostics(result)
+	if !jsonLines {
+		data, err := json.MarshalIndent(rdResult{Source: source, Diagnostics: diagnostics}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal rdjson: %w", err)
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, d := range diagnostics {
+		d.Source = &source
+		if err := enc.Encode(d); err != nil {
+			return fmt.Errorf("marshal rdjsonl: %w", err)
+		}
+	}
+	return nil
+}
+
+func rdDiagnostics(result *models.AnalysisResult) []rdDiagnostic {
+	diagnostics := []rdDiagnostic{}
+	src := sources{}
+	for _, r := range sortedResults(result.Results) {
+		severity, ok := rdSeverity[r.Status]
+		if !ok {
+			continue
+		}
+		code := r.Kind
+		if code == "" {
+			code = r.Status
+		}
+		d := rdDiagnostic{
+			Message:  findingMessage(r),
+			Location: rdLocation{Path: shortenPath(r.File), Range: rdRange{Start: rdPosition{Line: r.Line, Column: r.Column}}},
+			Severity: severity,
+			Code:     rdCode{Value: code, URL: docsURL},
+		}
+		// A relation written as a literal on the reported line spans it
+		if r.Column > 0 && literalAt(src.line(r.File, r.Line), r.Column, r.Relation) {
+			d.Location.Range.End = &rdPosition{Line: r.Line, Column: r.Column + len(r.Relation) + 2}
+			d.Suggestions = rdSuggestions(r)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// literalAt reports whether line holds relation as a string literal whose
+// opening quote is at the 1-based byte column col.
+func literalAt(line string, col int, relation string) bool {
+	i := col - 1
+	if i+len(relation)+2 > len(line) {
+		return false
+	}
+	quote := line[i]
+	return (quote == '"' || quote == '`') && line[i+1:i+1+len(relation)] == relation && line[i+1+len(relation)] == quote
+}
+
+// rdSuggestions turns r's did-you-mean candidates into replacements within
+// its relation literal: the failed segment for "not-found", the whole path
+// for "case-mismatch" and "column-name".
+func rdSuggestions(r models.PreloadResult) []rdSuggestion {
+	start, end := r.Column+1, r.Column+1+len(r.Relation)
+	switch {
+	case r.Kind == "case-mismatch" || r.Kind == "column-name":
+	case r.Kind == "not-found" && r.FailedSegment != nil:
+		parts := strings.Split(r.Relation, ".")
+		if *r.FailedSegment >= len(parts) {
+			return nil
+		}
+		start += len(strings.Join(parts[:*r.FailedSegment], "."))
+		if *r.FailedSegment > 0 {
+			start++
+		}
+		end = start + len(parts[*r.FailedSegment])
+	default:
+		return nil
+	}
+	var suggestions []rdSuggestion
+	for _, text := range r.Suggestion {
+		suggestions = append(suggestions, rdSuggestion{
+			Range: rdRange{Start: rdPosition{Line: r.Line, Column: start}, End: &rdPosition{Line: r.Line, Column: end}},
+			Text:  text,
+		})
+	}
+	return suggestions
+}
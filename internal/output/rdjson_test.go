@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteRDJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "order.go")
+	src := "package repo\n\nfunc Get(db *gorm.DB) {\n\tdb.Preload(\"User.Profil\").Find(&orders)\n\tdb.Preload(\"user\").Find(&orders)\n\tdb.Preload(rel).Find(&orders)\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	one, zero := 1, 0
+	results := []models.PreloadResult{
+		{File: file, Line: 4, Column: 13, Relation: "User.Profil", Status: "error", Kind: "not-found", Message: `User.Profil not found in repo.Order (did you mean "Profile"?)`, Suggestion: []string{"Profile"}, FailedSegment: &one},
+		{File: file, Line: 5, Column: 13, Relation: "user", Status: "error", Kind: "case-mismatch", Message: `user not found in repo.Order (did you mean "User"?)`, Suggestion: []string{"User"}, FailedSegment: &zero},
+		// rel is a constant: no range end, no suggestion
+		{File: file, Line: 6, Column: 13, Relation: "Usr", Status: "error", Kind: "not-found", Message: "Usr not found in repo.Order", Suggestion: []string{"User"}, FailedSegment: &zero},
+		{File: file, Line: 7, Relation: "Items", Status: "unknown", Kind: "unresolved-model", Message: "model could not be resolved"},
+		{File: file, Line: 8, Relation: "User", Status: "valid"},
+	}
+	result := report.Summarize(results)
+
+	var buf bytes.Buffer
+	if err := writeRDJSON(&buf, result, false); err != nil {
+		t.Fatal(err)
+	}
+	var got rdResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if got.Source.Name != "gpc" || len(got.Diagnostics) != 4 {
+		t.Fatalf("expected source gpc and 4 diagnostics, got %+v", got)
+	}
+
+	d := got.Diagnostics[0]
+	if d.Severity != "ERROR" || d.Code.Value != "not-found" || d.Code.URL == "" {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if want := (rdRange{Start: rdPosition{4, 13}, End: &rdPosition{4, 26}}); !reflect.DeepEqual(d.Location.Range, want) {
+		t.Errorf("range = %+v, want the literal %+v", d.Location.Range, want)
+	}
+	wantFix := []rdSuggestion{{Range: rdRange{Start: rdPosition{4, 19}, End: &rdPosition{4, 25}}, Text: "Profile"}}
+	if !reflect.DeepEqual(d.Suggestions, wantFix) {
+		t.Errorf("suggestions = %+v, want %+v", d.Suggestions, wantFix)
+	}
+	wantFix = []rdSuggestion{{Range: rdRange{Start: rdPosition{5, 14}, End: &rdPosition{5, 18}}, Text: "User"}}
+	if d := got.Diagnostics[1]; !reflect.DeepEqual(d.Suggestions, wantFix) {
+		t.Errorf("case-mismatch suggestions = %+v, want %+v", d.Suggestions, wantFix)
+	}
+	if d := got.Diagnostics[2]; d.Location.Range.End != nil || d.Suggestions != nil {
+		t.Errorf("expected no span for a non-literal relation, got %+v", d)
+	}
+	if d := got.Diagnostics[3]; d.Severity != "WARNING" || d.Message != "Items not verified: model could not be resolved" {
+		t.Errorf("unexpected unknown diagnostic %+v", d)
+	}
+
+	buf.Reset()
+	if err := writeRDJSON(&buf, result, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := 0
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var d rdDiagnostic
+		if err := json.Unmarshal(sc.Bytes(), &d); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+		if d.Source == nil || d.Source.Name != "gpc" {
+			t.Errorf("line %d: expected source gpc, got %+v", lines+1, d.Source)
+		}
+		lines++
+	}
+	if lines != 4 {
+		t.Errorf("expected 4 rdjsonl lines, got %d", lines)
+	}
+}
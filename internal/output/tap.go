@@ -0,0 +1,74 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// WriteTAPOutput writes results in the Test Anything Protocol, to stdout
+// when outputFile is empty: the plan, then one test line per result as it
+// is formatted. Errors are "not ok" with a YAML diagnostic block; results
+// that weren't verified are skipped, except that unknown ones fail under
+// style.FailUnknown.
+func WriteTAPOutput(result *models.AnalysisResult, outputFile string, style Style) error {
+	if outputFile == "" {
+		outputFile = Stdout
+	}
+	return streamFile(outputFile, func(w io.Writer) error {
+		return writeTAP(w, result, style)
+	})
+}
+
+func writeTAP(w io.Writer, result *models.AnalysisResult, style Style) error {
+	results := sortedResults(result.Results)
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(results)); err != nil {
+		return err
+	}
+	for i, r := range results {
+		desc := fmt.Sprintf("%d - %s:%d: %s", i+1, shortenPath(r.File), r.Line, r.Relation)
+		var err error
+		switch {
+		case r.Status == "error" || r.Status == "unknown" && style.FailUnknown:
+			_, err = fmt.Fprintf(w, "not ok %s\n%s", desc, tapDiagnostic(r))
+		case r.Status == "unknown" && r.Kind == "unresolved-model":
+			_, err = fmt.Fprintf(w, "ok %s # SKIP unknown model\n", desc)
+		case r.Status == "unknown":
+			_, err = fmt.Fprintf(w, "ok %s # SKIP %s\n", desc, r.Message)
+		case r.Status == "dynamic":
+			_, err = fmt.Fprintf(w, "ok %s # SKIP dynamic relation argument\n", desc)
+		case r.Status == "skipped" || r.Status == "suppressed":
+			_, err = fmt.Fprintf(w, "ok %s # SKIP %s\n", desc, r.Status)
+		default:
+			_, err = fmt.Fprintf(w, "ok %s\n", desc)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tapDiagnostic renders a failed result as an indented YAML block.
+func tapDiagnostic(r models.PreloadResult) string {
+	var b strings.Builder
+	b.WriteString("  ---\n")
+	fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(findingMessage(r)))
+	fmt.Fprintf(&b, "  model: %s\n", strconv.Quote(r.Model))
+	fmt.Fprintf(&b, "  status: %s\n", r.Status)
+	if r.Kind != "" {
+		fmt.Fprintf(&b, "  kind: %s\n", r.Kind)
+	}
+	if len(r.Suggestion) > 0 {
+		quoted := make([]string, len(r.Suggestion))
+		for i, s := range r.Suggestion {
+			quoted[i] = strconv.Quote(s)
+		}
+		fmt.Fprintf(&b, "  suggestion: [%s]\n", strings.Join(quoted, ", "))
+	}
+	b.WriteString("  ...\n")
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteTAP(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	results := []models.PreloadResult{
+		{File: file, Line: 10, Relation: "User", Model: "db.Order", Status: "valid"},
+		{File: file, Line: 15, Relation: "Usr", Model: "db.Order", Status: "error", Kind: "not-found", Message: `Usr not found in db.Order (did you mean "User"?)`, Suggestion: []string{"User"}},
+		{File: file, Line: 20, Relation: "Items", Model: "Unknown", Status: "unknown", Kind: "unresolved-model", Message: "model could not be resolved"},
+		{File: file, Line: 25, Relation: "Pet.Owner", Model: "db.Order", Status: "unknown", Kind: "interface-field", Message: "cannot traverse interface-typed field Owner"},
+		{File: file, Line: 30, Relation: "(dynamic)", Status: "dynamic"},
+		{File: file, Line: 35, Relation: "Audit", Model: "db.Order", Status: "suppressed"},
+	}
+	result := report.Summarize(results)
+
+	var buf bytes.Buffer
+	if err := writeTAP(&buf, result, Style{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `TAP version 13
+1..6
+ok 1 - order.go:10: User
+not ok 2 - order.go:15: Usr
+  ---
+  message: "Usr not found in db.Order (did you mean \"User\"?)"
+  model: "db.Order"
+  status: error
+  kind: not-found
+  suggestion: ["User"]
+  ...
+ok 3 - order.go:20: Items # SKIP unknown model
+ok 4 - order.go:25: Pet.Owner # SKIP cannot traverse interface-typed field Owner
+ok 5 - order.go:30: (dynamic) # SKIP dynamic relation argument
+ok 6 - order.go:35: Audit # SKIP suppressed
+`
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected TAP:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := writeTAP(&buf, result, Style{FailUnknown: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("not ok 3 - order.go:20: Items\n  ---\n")) {
+		t.Errorf("expected unknown results to fail under FailUnknown:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,113 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// TemplatePresets are named --format-template values.
+var TemplatePresets = map[string]string{
+	// emacs matches compilation-mode's file:line:column: message
+	"emacs": `{{.File}}:{{.Line}}:{{.Column}}: {{.Status}}: {{.Relation}}{{with .Message}}: {{.}}{{end}}`,
+	// vi matches the %f:%l:%c:%m entry of Vim's default errorformat
+	"vi":    `{{.File}}:{{.Line}}:{{.Column}}:{{.Relation}} {{.Status}}{{with .Message}}: {{.}}{{end}}`,
+	"short": `{{.File}}:{{.Line}}: {{.Relation}} {{.Status}}`,
+}
+
+// Templates formats results with user templates: Result once per
+// PreloadResult, then Summary, when set, once with the AnalysisResult.
+type Templates struct {
+	Result  *template.Template
+	Summary *template.Template
+}
+
+// ParseTemplates parses a --format-template (or the name of a preset) and
+// a --summary-template, which may be empty. Each is tried on a zero value
+// so a misspelled field fails before any analysis runs; errors list the
+// fields the template can use. Other errors of the trial, such as an
+// index out of range or a nil Stats, are left for the real data.
+func ParseTemplates(format, summary string) (*Templates, error) {
+	if preset, ok := TemplatePresets[format]; ok {
+		format = preset
+	}
+	result, err := parseTemplate("format-template", format, models.PreloadResult{})
+	if err != nil {
+		return nil, err
+	}
+	t := &Templates{Result: result}
+	if summary != "" {
+		if t.Summary, err = parseTemplate("summary-template", summary, models.AnalysisResult{}); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func parseTemplate(name, text string, sample any) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err == nil {
+		if err = tmpl.Execute(io.Discard, sample); err != nil && !strings.Contains(err.Error(), "can't evaluate field") {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w (fields: %s)", name, err, strings.Join(fieldNames(sample), ", "))
+	}
+	return tmpl, nil
+}
+
+// fieldNames lists the exported fields of v's struct type.
+func fieldNames(v any) []string {
+	typ := reflect.TypeOf(v)
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.IsExported() {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// WriteTemplateOutput writes result through t, to stdout when outputFile
+// is empty. Results are sorted like the JSON output and shown with paths
+// relative to the working directory; each gets a line of its own.
+func WriteTemplateOutput(result *models.AnalysisResult, t *Templates, outputFile string) error {
+	if outputFile == "" {
+		outputFile = Stdout
+	}
+	return streamFile(outputFile, func(w io.Writer) error {
+		return writeTemplates(w, result, t)
+	})
+}
+
+func writeTemplates(w io.Writer, result *models.AnalysisResult, t *Templates) error {
+	for _, r := range sortedResults(result.Results) {
+		r.File = shortenPath(r.File)
+		if err := executeLine(w, t.Result, r); err != nil {
+			return err
+		}
+	}
+	if t.Summary == nil {
+		return nil
+	}
+	return executeLine(w, t.Summary, result)
+}
+
+// executeLine executes tmpl with data and ends the output with a newline
+// unless the template already did.
+func executeLine(w io.Writer, tmpl *template.Template, data any) error {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("--%s: %w", tmpl.Name(), err)
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+func TestWriteTemplates(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "order.go")
+	result := report.Summarize([]models.PreloadResult{
+		{File: file, Line: 15, Column: 13, Relation: "Usr", Model: "db.Order", Status: "error", Message: "Usr not found in db.Order", Suggestion: []string{"User"}},
+		{File: file, Line: 10, Column: 13, Relation: "User", Model: "db.Order", Status: "valid"},
+	})
+
+	tests := []struct {
+		name, format, summary, want string
+	}{
+		{
+			name:   "emacs preset",
+			format: "emacs",
+			want:   "order.go:10:13: valid: User\norder.go:15:13: error: Usr: Usr not found in db.Order\n",
+		},
+		{
+			name:   "short preset",
+			format: "short",
+			want:   "order.go:10: User valid\norder.go:15: Usr error\n",
+		},
+		{
+			name:    "custom with summary",
+			format:  "{{.Relation}} on {{.Model}}{{range .Suggestion}} (did you mean {{.}}?){{end}}\n",
+			summary: "{{.Errors}}/{{.Total}}",
+			want:    "User on db.Order\nUsr on db.Order (did you mean User?)\n1/2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplates(tt.format, tt.summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := writeTemplates(&buf, result, tmpl); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplates_Errors(t *testing.T) {
+	tests := []struct {
+		format, summary, want string
+	}{
+		{"{{.File", "", "--format-template"},
+		{"{{.Fle}}", "", "fields: File, Line, Column, Relation"},
+		{"short", "{{.Eror}}", "--summary-template"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTemplates(tt.format, tt.summary)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseTemplates(%q, %q) = %v, want an error mentioning %q", tt.format, tt.summary, err, tt.want)
+		}
+	}
+}
+
+// Templates that only fail on the zero value the trial runs on are valid.
+func TestParseTemplates_ZeroValueErrors(t *testing.T) {
+	tests := []struct {
+		format, summary string
+	}{
+		{"{{index .Suggestion 0}}", ""},
+		{"short", "{{.Stats.Files}}"},
+		{"short", "{{.Meta.Files}}"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseTemplates(tt.format, tt.summary); err != nil {
+			t.Errorf("ParseTemplates(%q, %q): %v", tt.format, tt.summary, err)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package repo
+
+import "gorm.io/gorm"
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+	db.Preload("Usr").Find(&orders)
+	db.Preload("Items").Find(&orders)
+	db.Preload("User").Preload("User").Find(&orders)
+	db.Preload("Usr").Find(&orders)
+}
@@ -0,0 +1,111 @@
+package relations
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// columnNames lists the column names GORM gives st's fields: a
+// gorm:"column:..." tag, or the snake_case field name. Fields tagged
+// gorm:"-" have none; embedded structs (anonymous or gorm:"embedded")
+// contribute their fields, with any embeddedPrefix.
+func columnNames(st *types.Struct) []string {
+	seen := map[string]bool{}
+	visited := map[*types.Struct]bool{}
+	var names []string
+	var collect func(st *types.Struct, prefix string, depth int)
+	collect = func(st *types.Struct, prefix string, depth int) {
+		if visited[st] || depth > maxEmbedDepth {
+			return
+		}
+		visited[st] = true
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			tag := gormTag(st.Tag(i))
+			if !token.IsExported(field.Name()) || tag["-"] != nil {
+				continue
+			}
+			if _, embedded := tag["EMBEDDED"]; field.Embedded() || embedded {
+				if u := unwrapToStruct(field.Type()); u != nil {
+					p := prefix
+					if ep := tag["EMBEDDEDPREFIX"]; ep != nil {
+						p += *ep
+					}
+					collect(u.st, p, depth+1)
+					continue
+				}
+			}
+			name := prefix + toDBName(field.Name())
+			if c := tag["COLUMN"]; c != nil && *c != "" {
+				name = prefix + *c
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	collect(st, "", 0)
+	sort.Strings(names)
+	return names
+}
+
+// gormTag parses a struct tag's gorm settings ("column:name;not null") by
+// upper-cased key. A key without a value maps to a pointer to "".
+func gormTag(tag string) map[string]*string {
+	settings := map[string]*string{}
+	for _, item := range strings.Split(reflect.StructTag(tag).Get("gorm"), ";") {
+		key, value, _ := strings.Cut(item, ":")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		settings[key] = &value
+	}
+	return settings
+}
+
+// toDBName converts a Go field name to GORM's default snake_case column
+// name: UserID is user_id, HTTPServer is http_server.
+func toDBName(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// suggestColumns returns the names closest to col by edit distance, like
+// suggestFields.
+func suggestColumns(names []string, col string) []string {
+	best := maxSuggestDistance + 1
+	var out []string
+	for _, name := range names {
+		d := levenshtein(col, name)
+		switch {
+		case d > maxSuggestDistance:
+		case d < best:
+			best = d
+			out = []string{name}
+		case d == best:
+			out = append(out, name)
+		}
+	}
+	if len(out) > maxSuggestions {
+		out = out[:maxSuggestions]
+	}
+	return out
+}
@@ -9,11 +9,16 @@ import (
 )
 
 func loadAndCollect(t *testing.T, files map[string]string) []collector.Chain {
+	t.Helper()
+	return loadAndCollectWith(t, files, collector.Options{})
+}
+
+func loadAndCollectWith(t *testing.T, files map[string]string, opts collector.Options) []collector.Chain {
 	t.Helper()
 	dir := testutil.CreateTestModule(t, files)
 	result, err := loader.Load(dir)
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	return collector.Collect(result)
+	return collector.Collect(result, opts)
 }
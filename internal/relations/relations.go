@@ -3,55 +3,367 @@
 package relations
 
 import (
+	"fmt"
+	"go/token"
+	"path"
+	"strings"
+
 	"github.com/your-moon/gpc/internal/collector"
 	"github.com/your-moon/gpc/internal/models"
 )
 
+// Options tunes Verify. The zero value performs plain relation-path
+// verification with no extra heuristics.
+type Options struct {
+	// MaxHasManyHops, when positive, downgrades a verified relation path
+	// to a warning if it crosses more than this many has-many relations.
+	MaxHasManyHops int
+	// DynamicAsWarning reports non-constant relation arguments as warnings
+	// instead of giving them the neutral "dynamic" status.
+	DynamicAsWarning bool
+	// RedundantParents reports a preload as informational when a deeper
+	// path in the same chain already loads it, e.g. "Items" next to
+	// "Items.Product". Conditional preloads are never reported.
+	RedundantParents bool
+	// AllowDuplicates leaves a relation preloaded twice in the same chain
+	// valid instead of reporting the repeat as a "duplicate-preload"
+	// warning.
+	AllowDuplicates bool
+	// Models, when non-empty, restricts verification to models whose name
+	// matches one of these path.Match patterns ("Invoice", "Trip*"; a
+	// pattern with a dot matches "pkg.Name"). Preloads on other models,
+	// or on models that can't be resolved, are reported as "skipped".
+	Models []string
+	// IgnoreModels and IgnoreRelations hold path.Match patterns, matched
+	// like Models and against the whole relation path respectively.
+	// Findings they match are reported as "suppressed", as if covered by
+	// a //gpc:ignore directive.
+	IgnoreModels    []string
+	IgnoreRelations []string
+	// Severity overrides the status of findings by kind, e.g.
+	// {"has-many-depth": "error"}. Levels are "error", "warning" and
+	// "info"; it applies only to results that already have one of them.
+	Severity map[string]string
+	// ScalarTypes names extra types, as "package/path.Name", that hold a
+	// column value rather than a relation, like the built-in time.Time and
+	// database/sql.NullString. Preloading a field of one is an error.
+	ScalarTypes []string
+	// CheckSelectColumns checks the columns a Preload scope function
+	// selects (db.Select("id, name")) against the column names of the
+	// relation's struct, reporting unknown ones as errors.
+	CheckSelectColumns bool
+	// Explain sets each result's Explain trace of how its model was
+	// resolved.
+	Explain bool
+}
+
 // Verify resolves the model for each chain and verifies every relation
 // path against that model's type graph.
-func Verify(chains []collector.Chain) []models.PreloadResult {
+func Verify(chains []collector.Chain, opts Options) []models.PreloadResult {
 	var results []models.PreloadResult
+	scalars := map[string]bool{}
+	for _, name := range opts.ScalarTypes {
+		scalars[name] = true
+	}
 	for _, chain := range chains {
 		m := resolveModel(chain)
-		for _, p := range chain.Preloads {
-			results = append(results, verifyPreload(chain, m, p))
+		ignoreModel := allowed(m, opts.IgnoreModels)
+		chainResults := make([]models.PreloadResult, len(chain.Preloads))
+		// Unknown selected columns are results of their own, so they keep
+		// the preload's result and position
+		var columnResults []models.PreloadResult
+		var columnPreloads []collector.PreloadInfo
+		for i, p := range chain.Preloads {
+			var column *models.PreloadResult
+			chainResults[i], column = verifyPreload(chain, m, p, scalars, opts)
+			if column != nil {
+				columnResults = append(columnResults, *column)
+				columnPreloads = append(columnPreloads, p)
+			}
+		}
+		if !opts.AllowDuplicates {
+			markDuplicates(chain.Preloads, chainResults)
 		}
+		if opts.RedundantParents {
+			markRedundant(chain.Preloads, chainResults)
+		}
+		applySeverity(chainResults, opts.Severity)
+		applySeverity(columnResults, opts.Severity)
+		markSuppressed(chain.Preloads, chainResults, ignoreModel, opts.IgnoreRelations)
+		markSuppressed(columnPreloads, columnResults, ignoreModel, opts.IgnoreRelations)
+		results = append(results, chainResults...)
+		results = append(results, columnResults...)
 	}
 	return results
 }
 
-func verifyPreload(chain collector.Chain, m *model, p collector.PreloadInfo) models.PreloadResult {
+// markDuplicates downgrades a verified preload to a warning when an earlier
+// preload in the same chain already names the same relation. results holds
+// one entry per preload, in the same order.
+func markDuplicates(preloads []collector.PreloadInfo, results []models.PreloadResult) {
+	firstLine := map[string]int{}
+	for i, p := range preloads {
+		if p.Dynamic {
+			continue
+		}
+		line, dup := firstLine[p.Relation]
+		if !dup {
+			firstLine[p.Relation] = p.Line
+			continue
+		}
+		if results[i].Status == "valid" {
+			results[i].Status = "warning"
+			results[i].Kind = "duplicate-preload"
+			results[i].Message = fmt.Sprintf("duplicate preload of %s (lines %d and %d)", p.Relation, line, p.Line)
+		}
+	}
+}
+
+// markSuppressed turns findings covered by a //gpc:ignore directive, on an
+// ignored model, or on a relation matching one of ignoreRelations into
+// "suppressed" results. Kind and Message are kept so they can be audited.
+func markSuppressed(preloads []collector.PreloadInfo, results []models.PreloadResult, ignoreModel bool, ignoreRelations []string) {
+	for i, p := range preloads {
+		if results[i].Status == "valid" {
+			continue
+		}
+		if p.Suppressed || ignoreModel || matchAny(ignoreRelations, p.Relation) {
+			results[i].Status = "suppressed"
+		}
+	}
+}
+
+// applySeverity replaces the status of error, warning and info results
+// whose kind has an override.
+func applySeverity(results []models.PreloadResult, severity map[string]string) {
+	for i, r := range results {
+		level, ok := severity[r.Kind]
+		if !ok {
+			continue
+		}
+		switch r.Status {
+		case "error", "warning", "info":
+			results[i].Status = level
+		}
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// markRedundant downgrades a verified, unconditional preload to info when a
+// verified deeper path in the same chain starts with it, since GORM loads
+// every parent of a nested preload on its own.
+func markRedundant(preloads []collector.PreloadInfo, results []models.PreloadResult) {
+	for i, p := range preloads {
+		if p.Dynamic || p.Conditional || results[i].Status != "valid" || p.Relation == "clause.Associations" {
+			continue
+		}
+		for j, q := range preloads {
+			if q.Dynamic || results[j].Status == "error" || !strings.HasPrefix(q.Relation, p.Relation+".") {
+				continue
+			}
+			results[i].Status = "info"
+			results[i].Kind = "redundant-preload"
+			results[i].Message = fmt.Sprintf("%s is already loaded by %s (line %d)", p.Relation, q.Relation, q.Line)
+			break
+		}
+	}
+}
+
+// verifyPreload verifies p against m. Under opts.CheckSelectColumns it
+// also returns the result for a column p's scope selects that the
+// relation doesn't have, or nil.
+func verifyPreload(chain collector.Chain, m *model, p collector.PreloadInfo, scalars map[string]bool, opts Options) (models.PreloadResult, *models.PreloadResult) {
 	res := models.PreloadResult{
-		File:     chain.File,
-		Line:     p.Line,
-		Relation: p.Relation,
-		Model:    modelDisplay(m),
+		File:        chain.File,
+		Line:        p.Line,
+		Column:      p.Column,
+		Relation:    p.Relation,
+		Model:       modelDisplay(m),
+		Variable:    destination(chain),
+		Source:      chain.Source,
+		Func:        chain.Func,
+		PreloadExpr: p.Expr,
+	}
+	if opts.Explain {
+		res.Explain = explain(chain, m)
 	}
 
-	if p.Dynamic {
+	if len(opts.Models) > 0 && !allowed(m, opts.Models) {
 		res.Status = "skipped"
-		res.Relation = "(dynamic)"
-		return res
+		res.Kind = "not-allowlisted"
+		res.Message = "model is not in the allowlist"
+		return res, nil
+	}
+	if p.Dynamic {
+		if p.Relation == "" {
+			res.Relation = "(dynamic)"
+			res.Message = "dynamic relation argument cannot be verified"
+		} else {
+			// A known relation that couldn't be tied to a query's model
+			res.Message = "preload option is not passed to a query with a resolvable model"
+		}
+		res.Status = "dynamic"
+		if opts.DynamicAsWarning {
+			res.Status = "warning"
+			res.Kind = "dynamic"
+		}
+		return res, nil
 	}
 	if p.Relation == "clause.Associations" {
 		res.Status = "valid"
-		return res
+		return res, nil
+	}
+	if strings.TrimSpace(p.Relation) == "" {
+		res.Status = "error"
+		res.Kind = "empty-relation"
+		res.Message = "empty preload relation"
+		return res, nil
 	}
-	if p.Relation == "" {
+	// An unexported identifier may still be a miscased field name, which
+	// only the walk can tell
+	malformed := malformedSegment(p.Relation)
+	if malformed >= 0 && (m == nil || !token.IsIdentifier(strings.Split(p.Relation, ".")[malformed])) {
 		res.Status = "error"
-		return res
+		res.Kind = "malformed-path"
+		res.Message = malformedMessage(p.Relation, malformed)
+		res.FailedSegment = &malformed
+		return res, nil
 	}
 	if m == nil {
-		res.Status = "skipped"
-		return res
+		res.Status = "unknown"
+		res.Kind = "unresolved-model"
+		res.Message = unresolvedMessage(chain)
+		return res, nil
 	}
 
-	if m.walk(p.Relation).ok {
+	wr := m.walk(p.Relation, scalars)
+	switch {
+	case wr.ok && wr.column != "":
+		res.Status = "error"
+		res.Kind = "column-name"
+		res.Message = fmt.Sprintf("preload expects the Go field name %q, not the column %q", wr.columnField, wr.column)
+		res.Suggestion = []string{wr.corrected}
+		res.FailedSegment = &wr.columnAt
+	case wr.ok && wr.corrected != "":
+		res.Status = "error"
+		res.Kind = "case-mismatch"
+		res.Message = fmt.Sprintf("%s not found in %s", p.Relation, res.Model) + didYouMean([]string{wr.corrected})
+		res.Suggestion = []string{wr.corrected}
+		res.FailedSegment = firstDifference(p.Relation, wr.corrected)
+	case malformed >= 0:
+		res.Status = "error"
+		res.Kind = "malformed-path"
+		res.Message = malformedMessage(p.Relation, malformed)
+		res.FailedSegment = &malformed
+	case wr.unpreloadable != "":
+		res.Status = "error"
+		res.Kind = "not-preloadable"
+		res.Message = fmt.Sprintf("%s is not a preloadable relation (%s field of type %s)", strings.Join(strings.Split(p.Relation, ".")[:wr.failedAt+1], "."), wr.unpreloadable, wr.fieldType)
+		res.FailedSegment = &wr.failedAt
+	case wr.opaque:
+		res.Status = "unknown"
+		res.Kind = "interface-field"
+		res.Message = fmt.Sprintf("cannot traverse interface-typed field %s", strings.Split(p.Relation, ".")[wr.failedAt])
+		res.FailedSegment = &wr.failedAt
+	case !wr.ok && wr.failedAt == 0 && chain.Terminal.Method == "Scan" && isProjection(m, scalars):
+		// GORM preloads into the query's model, never into a Scan target
+		res.Status = "skipped"
+		res.Kind = "scan-projection"
+		res.Message = fmt.Sprintf("Scan destination %s is a projection without relations; preloads do not apply to it", res.Model)
+	case !wr.ok:
+		res.Status = "error"
+		res.Kind = "not-found"
+		res.Message = fmt.Sprintf("%s not found in %s", p.Relation, res.Model) + didYouMean(wr.suggestions)
+		res.Suggestion = wr.suggestions
+		res.FailedSegment = &wr.failedAt
+	case opts.MaxHasManyHops > 0 && wr.hasMany > opts.MaxHasManyHops:
+		res.Status = "warning"
+		res.Kind = "has-many-depth"
+		res.Message = fmt.Sprintf("%s crosses %d has-many relations (limit %d)", p.Relation, wr.hasMany, opts.MaxHasManyHops)
+	default:
 		res.Status = "valid"
-	} else {
+	}
+	if wr.ok && wr.target != nil {
+		res.RelationType = wr.relation
+		res.TargetModel = modelDisplay(wr.target)
+	}
+	if opts.CheckSelectColumns && wr.ok && res.Status != "error" {
+		return res, checkSelects(res, p.Selects, wr.target)
+	}
+	return res, nil
+}
+
+// checkSelects returns an "unknown-column" error for the first of selects
+// that names no column of target, positioned at the column, or nil. It
+// starts from res, the result of the preload whose scope selects them.
+func checkSelects(res models.PreloadResult, selects []collector.SelectColumn, target *model) *models.PreloadResult {
+	if target == nil || len(selects) == 0 {
+		return nil
+	}
+	names := columnNames(target.structType)
+	known := map[string]bool{}
+	for _, name := range names {
+		known[strings.ToLower(name)] = true
+	}
+	for _, sel := range selects {
+		if known[strings.ToLower(sel.Name)] {
+			continue
+		}
+		suggestions := suggestColumns(names, sel.Name)
 		res.Status = "error"
+		res.Kind = "unknown-column"
+		res.Message = fmt.Sprintf("column %q selected for %s not found in %s", sel.Name, res.Relation, modelDisplay(target)) + didYouMean(suggestions)
+		res.Suggestion = suggestions
+		res.FailedSegment = nil
+		res.Line, res.Column = sel.Line, sel.Column
+		return &res
+	}
+	return nil
+}
+
+// firstDifference returns the index of the first segment where two dotted
+// paths of the same shape differ.
+func firstDifference(a, b string) *int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		if as[i] != bs[i] {
+			return &i
+		}
+	}
+	return nil
+}
+
+// allowed reports whether m matches one of the allowlist patterns.
+func allowed(m *model, patterns []string) bool {
+	if m == nil {
+		return false
+	}
+	for _, pat := range patterns {
+		name := m.name
+		if strings.Contains(pat, ".") {
+			name = modelDisplay(m)
+		}
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func malformedMessage(path string, i int) string {
+	seg := strings.Split(path, ".")[i]
+	if seg == "" {
+		return fmt.Sprintf("malformed preload path %q: segment %d is empty", path, i)
 	}
-	return res
+	return fmt.Sprintf("malformed preload path %q: segment %d %q is not an exported Go identifier", path, i, seg)
 }
 
 func modelDisplay(m *model) string {
@@ -1,6 +1,9 @@
 package relations
 
 import (
+	"fmt"
+	"go/ast"
+	"go/token"
 	"go/types"
 
 	"github.com/your-moon/gpc/internal/collector"
@@ -20,6 +23,7 @@ type fieldInfo struct {
 	typ        types.Type
 	structType *types.Struct // non-nil if the field's type unwraps to a struct
 	named      *types.Named  // non-nil if the field's type is named
+	tag        string
 }
 
 // resolveModel determines the model from a chain's terminal call argument.
@@ -34,12 +38,77 @@ func resolveModel(chain collector.Chain) *model {
 	return extractModel(argType)
 }
 
-// extractModel unwraps pointer/slice/array types to find the underlying named struct.
+// destination returns the terminal call's argument as written, without a
+// leading & ("orders", "resp.Items"). It comes from the AST, so chains
+// spread over several lines name it too.
+func destination(chain collector.Chain) string {
+	if chain.Terminal == nil || chain.Terminal.Arg == nil {
+		return ""
+	}
+	arg := ast.Unparen(chain.Terminal.Arg)
+	if u, ok := arg.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		arg = u.X
+	}
+	return types.ExprString(arg)
+}
+
+// sources describes each collector.Chain Source for explain.
+var sources = map[string]string{
+	"chain":    "same chain",
+	"variable": "query variable",
+	"field":    "option field",
+}
+
+// explain traces how chain's model was resolved, e.g.
+// "Find(&orders) on line 14 (same chain): model repo.Order from type *[]Order".
+func explain(chain collector.Chain, m *model) string {
+	if chain.Terminal == nil || chain.Terminal.Arg == nil || chain.Pkg == nil {
+		return fmt.Sprintf("no query receives the relation names (%s)", sources[chain.Source])
+	}
+	call := fmt.Sprintf("%s(%s) on line %d (%s)", chain.Terminal.Method,
+		types.ExprString(chain.Terminal.Arg), chain.Pkg.Fset.Position(chain.Terminal.Pos).Line, sources[chain.Source])
+	typ := chain.Pkg.TypesInfo.TypeOf(chain.Terminal.Arg)
+	if typ == nil {
+		return call + ": type of the destination unknown"
+	}
+	from := typeString(typ, chain.Pkg.Types)
+	if m == nil {
+		return fmt.Sprintf("%s: no model struct in type %s", call, from)
+	}
+	return fmt.Sprintf("%s: model %s from type %s", call, modelDisplay(m), from)
+}
+
+// unresolvedMessage says why chain has no model: the destination's type,
+// when it has one, holds no model struct, or is an interface whose model
+// only the caller knows.
+func unresolvedMessage(chain collector.Chain) string {
+	if chain.Terminal != nil && chain.Terminal.Arg != nil && chain.Pkg != nil {
+		if typ := chain.Pkg.TypesInfo.TypeOf(chain.Terminal.Arg); typ != nil {
+			if types.IsInterface(typ) {
+				return fmt.Sprintf("destination is an interface (%s); its model is only known at run time", typeString(typ, chain.Pkg.Types))
+			}
+			return fmt.Sprintf("destination is not a model struct (%s)", typeString(typ, chain.Pkg.Types))
+		}
+	}
+	return "model could not be resolved"
+}
+
+// extractModel unwraps aliases and pointer/slice/array types to find the
+// underlying named struct. gorm.DB holds a query and the built-in scalar
+// types (time.Time, sql.NullString, ...) a column value, so neither is a
+// model. A generic wrapper struct (Page[Invoice]) resolves to the model
+// it holds; see typeArgModel.
 func extractModel(typ types.Type) *model {
-	typ = deref(typ)
+	typ = types.Unalias(deref(types.Unalias(typ)))
 	switch t := typ.(type) {
 	case *types.Named:
+		if name := qualifiedName(t); name == "gorm.io/gorm.DB" || scalarTypes[name] {
+			return nil
+		}
 		if st, ok := t.Underlying().(*types.Struct); ok {
+			if m := typeArgModel(t); m != nil {
+				return m
+			}
 			return &model{
 				name:       t.Obj().Name(),
 				pkg:        t.Obj().Pkg(),
@@ -58,6 +127,63 @@ func extractModel(typ types.Type) *model {
 	return nil
 }
 
+// isProjection reports whether m looks like a DTO that Scan fills rather
+// than a GORM model: it has no TableName method, does not embed a struct
+// (gorm.Model or a base model) and has no field that could be a relation.
+// scalars holds extra scalar types, as in scalarTypes.
+func isProjection(m *model, scalars map[string]bool) bool {
+	if m.named != nil {
+		if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(m.named), true, m.pkg, "TableName"); obj != nil {
+			return false
+		}
+	}
+	for i := 0; i < m.structType.NumFields(); i++ {
+		field := m.structType.Field(i)
+		if unwrapToStruct(field.Type()) == nil || unpreloadableKind(field.Type(), scalars) != "" {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// typeArgModel returns the model a generic wrapper struct holds in a T,
+// []T or *T field, as Page[T] does in Items []T. A wrapper with relation
+// fields of its own (Audited[T] with Changes []Change) is the model
+// itself, and so is one holding no T (Repository[T] with a *gorm.DB).
+func typeArgModel(t *types.Named) *model {
+	st, ok := t.Origin().Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var held *model
+	for i := 0; i < st.NumFields(); i++ {
+		typ := st.Field(i).Type()
+		if param := heldParam(typ); param != nil {
+			if held == nil {
+				held = extractModel(t.TypeArgs().At(param.Index()))
+			}
+			continue
+		}
+		if unwrapToStruct(typ) != nil && unpreloadableKind(typ, nil) == "" {
+			return nil
+		}
+	}
+	return held
+}
+
+// heldParam returns the type parameter of a T, []T or *T field type.
+func heldParam(typ types.Type) *types.TypeParam {
+	switch t := typ.(type) {
+	case *types.Pointer:
+		typ = t.Elem()
+	case *types.Slice:
+		typ = t.Elem()
+	}
+	param, _ := typ.(*types.TypeParam)
+	return param
+}
+
 // deref removes one layer of pointer indirection (for &variable).
 func deref(typ types.Type) types.Type {
 	if ptr, ok := typ.(*types.Pointer); ok {
@@ -66,12 +192,25 @@ func deref(typ types.Type) types.Type {
 	return typ
 }
 
+// maxEmbedDepth bounds how deep lookupField and fieldNames descend through
+// embedded structs. Together with their visited sets it keeps
+// self-embedding types (type Node struct{ *Node }) from recursing forever.
+const maxEmbedDepth = 16
+
 // lookupField finds a field by name in a struct, including promoted (embedded) fields.
 func lookupField(st *types.Struct, name string) *fieldInfo {
+	return lookupFieldIn(st, name, map[*types.Struct]bool{}, 0)
+}
+
+func lookupFieldIn(st *types.Struct, name string, visited map[*types.Struct]bool, depth int) *fieldInfo {
+	if visited[st] || depth > maxEmbedDepth {
+		return nil
+	}
+	visited[st] = true
 	for i := 0; i < st.NumFields(); i++ {
 		field := st.Field(i)
 		if field.Name() == name {
-			fi := &fieldInfo{name: field.Name(), typ: field.Type()}
+			fi := &fieldInfo{name: field.Name(), typ: field.Type(), tag: st.Tag(i)}
 			if u := unwrapToStruct(field.Type()); u != nil {
 				fi.structType = u.st
 				fi.named = u.named
@@ -85,7 +224,7 @@ func lookupField(st *types.Struct, name string) *fieldInfo {
 			continue
 		}
 		if u := unwrapToStruct(field.Type()); u != nil {
-			if found := lookupField(u.st, name); found != nil {
+			if found := lookupFieldIn(u.st, name, visited, depth+1); found != nil {
 				return found
 			}
 		}
@@ -98,27 +237,33 @@ type structInfo struct {
 	named *types.Named
 }
 
+// unwrapToStruct peels alias, pointer, slice, and array layers (including
+// named slice types such as `type Items []Item`) until it reaches a struct.
 func unwrapToStruct(typ types.Type) *structInfo {
-	typ = derefAll(typ)
-	switch t := typ.(type) {
-	case *types.Slice:
-		typ = derefAll(t.Elem())
-	case *types.Array:
-		typ = derefAll(t.Elem())
-	}
-	if named, ok := typ.(*types.Named); ok {
-		if st, ok := named.Underlying().(*types.Struct); ok {
-			return &structInfo{st: st, named: named}
+	for {
+		switch t := types.Unalias(typ).(type) {
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		case *types.Array:
+			typ = t.Elem()
+		case *types.Named:
+			if st, ok := t.Underlying().(*types.Struct); ok {
+				return &structInfo{st: st, named: t}
+			}
+			typ = t.Underlying()
+		case *types.Struct:
+			return &structInfo{st: t}
+		default:
+			return nil
 		}
 	}
-	if st, ok := typ.(*types.Struct); ok {
-		return &structInfo{st: st}
-	}
-	return nil
 }
 
 func derefAll(typ types.Type) types.Type {
 	for {
+		typ = types.Unalias(typ)
 		ptr, ok := typ.(*types.Pointer)
 		if !ok {
 			return typ
@@ -107,3 +107,317 @@ type Order struct {
 		t.Errorf("expected package 'models', got %v", m.pkg)
 	}
 }
+
+func TestResolveModel_ShadowedDestination(t *testing.T) {
+	// The inner `invoices` shadows the outer one with a different element
+	// type. Resolution goes through the type checker's object for the
+	// identifier at the Find call, so each chain gets its own declaration.
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Customer struct {
+	ID int64
+}
+
+type Driver struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID       int64
+	Customer Customer
+}
+
+type Trip struct {
+	ID     int64
+	Driver Driver
+}
+
+func Load(db *gorm.DB, both bool) {
+	var invoices []Invoice
+	if both {
+		invoices := []Trip{}
+		db.Preload("Driver").Find(&invoices)
+	}
+	db.Preload("Customer").Find(&invoices)
+}
+`,
+	})
+	if len(chains) != 2 {
+		t.Fatalf("expected 2 chains, got %d", len(chains))
+	}
+	for i, want := range []string{"Trip", "Invoice"} {
+		m := resolveModel(chains[i])
+		if m == nil {
+			t.Fatalf("chain %d: expected resolved model, got nil", i)
+		}
+		if m.name != want {
+			t.Errorf("chain %d: expected model '%s', got '%s'", i, want, m.name)
+		}
+	}
+	for _, r := range Verify(chains, Options{}) {
+		if r.Status != "valid" {
+			t.Errorf("%s on %s: expected 'valid', got '%s'", r.Relation, r.Model, r.Status)
+		}
+	}
+}
+
+func TestResolveModel_PointerAndSliceLayers(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Product struct {
+	ID int64
+}
+
+type Item struct {
+	Product Product
+}
+
+type Items []Item
+
+type Invoice struct {
+	Lines   []*Item
+	Batches *[]Item
+	Grouped [][]Item
+	Named   Items
+}
+
+func Load(db *gorm.DB) {
+	var ptrSlice []*Invoice
+	db.Preload("Lines.Product").Find(&ptrSlice)
+
+	slicePtr := &[]Invoice{}
+	db.Preload("Batches.Product").Find(&slicePtr)
+
+	var one *Invoice
+	db.Preload("Grouped.Product").First(&one)
+
+	var named []Invoice
+	db.Preload("Named.Product").Find(&named)
+}
+`,
+	})
+	if len(chains) != 4 {
+		t.Fatalf("expected 4 chains, got %d", len(chains))
+	}
+	for i, chain := range chains {
+		m := resolveModel(chain)
+		if m == nil || m.name != "Invoice" {
+			t.Errorf("chain %d: expected model 'Invoice', got %+v", i, m)
+		}
+	}
+	for _, r := range Verify(chains, Options{}) {
+		if r.Status != "valid" {
+			t.Errorf("%s: expected 'valid', got '%s' (%s)", r.Relation, r.Status, r.Message)
+		}
+	}
+}
+
+func TestResolveModel_RepositoryFieldDestination(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Customer struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID       int64
+	Customer Customer
+}
+
+type InvoiceRepo struct {
+	db    *gorm.DB
+	items []*Invoice
+	one   Invoice
+}
+
+func (r *InvoiceRepo) Load(db *gorm.DB) {
+	db.Preload("Customer").Find(&r.items)
+}
+
+func (r *InvoiceRepo) LoadOne() {
+	r.db.Preload("Custmer").First(&r.one)
+}
+`,
+	})
+	if len(chains) != 2 {
+		t.Fatalf("expected 2 chains, got %d", len(chains))
+	}
+	for i, chain := range chains {
+		m := resolveModel(chain)
+		if m == nil || m.name != "Invoice" {
+			t.Errorf("chain %d: expected model 'Invoice', got %+v", i, m)
+		}
+	}
+	results := Verify(chains, Options{})
+	if results[0].Status != "valid" {
+		t.Errorf("expected 'valid' for Customer, got '%s'", results[0].Status)
+	}
+	if results[1].Status != "error" {
+		t.Errorf("expected 'error' for Custmer, got '%s'", results[1].Status)
+	}
+}
+
+func TestResolveModel_SingleRecordFinishers(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Org struct {
+	ID int64
+}
+
+type Role struct {
+	ID  int64
+	Org Org
+}
+
+func Load(db *gorm.DB, id int64) {
+	var role Role
+	db.Preload("Org").First(&role)
+	db.Preload("Org").First(&role, id)
+	db.Preload("Org").Last(&role)
+	db.Preload("Org").Take(&role, "id = ?", id)
+	db.Preload("Org").Scan(&role)
+	db.Preload("Org").FirstOrCreate(&role)
+	db.Preload("Org").FirstOrInit(&role)
+}
+`,
+	})
+	methods := []string{"First", "First", "Last", "Take", "Scan", "FirstOrCreate", "FirstOrInit"}
+	if len(chains) != len(methods) {
+		t.Fatalf("expected %d chains, got %d", len(methods), len(chains))
+	}
+	for i, chain := range chains {
+		if chain.Terminal.Method != methods[i] {
+			t.Errorf("chain %d: expected terminal %s, got %s", i, methods[i], chain.Terminal.Method)
+		}
+		if m := resolveModel(chain); m == nil || m.name != "Role" {
+			t.Errorf("chain %d (%s): expected model 'Role', got %+v", i, methods[i], m)
+		}
+	}
+}
+
+func TestResolveModel_NonModelDestinations(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func Query(db *gorm.DB) {
+	var createdAt time.Time
+	db.Preload("User").Find(db)
+	db.Preload("User").Find(&db)
+	db.Preload("User").First(&createdAt)
+}
+`,
+	})
+	if len(chains) != 3 {
+		t.Fatalf("expected 3 chains, got %d", len(chains))
+	}
+	for _, c := range chains {
+		if m := resolveModel(c); m != nil {
+			t.Errorf("%s(%s): expected no model, got %s", c.Terminal.Method, destination(c), modelDisplay(m))
+		}
+	}
+}
+
+func TestResolveModel_GenericDestinations(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Customer struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID       int64
+	Customer Customer
+}
+
+type List[T any] []T
+
+type Page[T any] struct {
+	Items []T
+	Total int64
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value *V
+}
+
+type Repository[T any] struct {
+	db *gorm.DB
+}
+
+type Change struct {
+	ID      int64
+	Comment string
+}
+
+type Audited[T any] struct {
+	ID      int64
+	Record  T
+	Changes []Change
+}
+
+func (r *Repository[T]) All() []T {
+	var out List[T]
+	r.db.Preload("Customer").Find(&out)
+	return out
+}
+
+func Load(db *gorm.DB) {
+	var list List[Invoice]
+	db.Preload("Customer").Find(&list)
+
+	var page Page[Invoice]
+	db.Preload("Customer").Find(&page)
+
+	var pair Pair[string, Invoice]
+	db.Preload("Customer").Find(&pair)
+
+	var repo Repository[Invoice]
+	db.Preload("Customer").Find(&repo)
+
+	var audited Audited[Invoice]
+	db.Preload("Changes").Find(&audited)
+}
+`,
+	})
+	if len(chains) != 6 {
+		t.Fatalf("expected 6 chains, got %d", len(chains))
+	}
+	if m := resolveModel(chains[0]); m != nil {
+		t.Errorf("List[T]: expected no model for a type parameter, got %s", modelDisplay(m))
+	}
+	// Wrappers that hold no T, or have relations of their own, are models
+	for i, name := range []string{"Invoice", "Invoice", "Invoice", "Repository", "Audited"} {
+		if m := resolveModel(chains[i+1]); m == nil || m.name != name {
+			t.Errorf("%s: expected model %q, got %+v", destination(chains[i+1]), name, m)
+		}
+	}
+	results := Verify(chains[1:], Options{})
+	want := []string{"valid", "valid", "valid", "error", "valid"}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: expected %q, got %q (%s)", r.Relation, want[i], r.Status, r.Message)
+		}
+	}
+}
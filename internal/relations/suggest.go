@@ -0,0 +1,109 @@
+package relations
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// maxSuggestDistance is the largest edit distance at which a field name is
+// offered as a did-you-mean candidate.
+const maxSuggestDistance = 2
+
+// maxSuggestions caps how many equally close candidates are offered.
+const maxSuggestions = 3
+
+// suggestFields returns the exported field names of st (promoted fields
+// included) closest to seg by edit distance, if any are within
+// maxSuggestDistance. Ties are sorted alphabetically and capped at
+// maxSuggestions.
+func suggestFields(st *types.Struct, seg string) []string {
+	best := maxSuggestDistance + 1
+	var out []string
+	for _, name := range fieldNames(st) {
+		d := levenshtein(seg, name)
+		switch {
+		case d > maxSuggestDistance:
+		case d < best:
+			best = d
+			out = []string{name}
+		case d == best:
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	if len(out) > maxSuggestions {
+		out = out[:maxSuggestions]
+	}
+	return out
+}
+
+// fieldNames lists the exported field names reachable by lookupField,
+// without duplicates.
+func fieldNames(st *types.Struct) []string {
+	seen := map[string]bool{}
+	visited := map[*types.Struct]bool{}
+	var names []string
+	var collect func(st *types.Struct, depth int)
+	collect = func(st *types.Struct, depth int) {
+		if visited[st] || depth > maxEmbedDepth {
+			return
+		}
+		visited[st] = true
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			if token.IsExported(field.Name()) && !seen[field.Name()] {
+				seen[field.Name()] = true
+				names = append(names, field.Name())
+			}
+			if field.Embedded() {
+				if u := unwrapToStruct(field.Type()); u != nil {
+					collect(u.st, depth+1)
+				}
+			}
+		}
+	}
+	collect(st, 0)
+	return names
+}
+
+// didYouMean renders candidates as ` (did you mean "A", "B" or "C"?)`, or
+// "" when there are none.
+func didYouMean(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(candidates))
+	for i, c := range candidates {
+		quoted[i] = fmt.Sprintf("%q", c)
+	}
+	list := quoted[0]
+	if n := len(quoted); n > 1 {
+		list = strings.Join(quoted[:n-1], ", ") + " or " + quoted[n-1]
+	}
+	return fmt.Sprintf(" (did you mean %s?)", list)
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,86 @@
+package relations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"User", "User", 0},
+		{"Usr", "User", 1},
+		{"Custmer", "Customer", 1},
+		{"Profiel", "Profile", 2},
+		{"", "Items", 5},
+		{"Ordrs", "Orders", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestFields(t *testing.T) {
+	m := modelFromFixture(t, `package main
+
+import "gorm.io/gorm"
+
+type Base struct {
+	Creator string
+}
+
+type Order struct {
+	Base
+	User  string
+	Users string
+	Uses  string
+	User2 string
+	Usera string
+	Items string
+	note  string
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+}
+`)
+	tests := []struct {
+		seg  string
+		want []string
+	}{
+		{"Itms", []string{"Items"}},
+		{"Creater", []string{"Creator"}},
+		{"Usr", []string{"User"}},
+		{"Usex", []string{"User", "Uses"}},
+		{"Userx", []string{"User", "User2", "Usera"}},
+		{"Note", nil},
+		{"Payments", nil},
+	}
+	for _, tt := range tests {
+		if got := suggestFields(m.structType, tt.seg); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("suggestFields(%q) = %v, want %v", tt.seg, got, tt.want)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"User"}, ` (did you mean "User"?)`},
+		{[]string{"User", "Uses"}, ` (did you mean "User" or "Uses"?)`},
+		{[]string{"A", "B", "C"}, ` (did you mean "A", "B" or "C"?)`},
+	}
+	for _, tt := range tests {
+		if got := didYouMean(tt.in); got != tt.want {
+			t.Errorf("didYouMean(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
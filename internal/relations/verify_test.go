@@ -1,6 +1,13 @@
 package relations
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/collector"
+	"github.com/your-moon/gpc/internal/models"
+)
 
 func TestVerify_SimpleValid(t *testing.T) {
 	chains := loadAndCollect(t, map[string]string{
@@ -25,7 +32,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -57,13 +64,16 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
 	if results[0].Status != "error" {
 		t.Errorf("expected 'error', got '%s'", results[0].Status)
 	}
+	if results[0].Message != "Customer not found in main.Order" {
+		t.Errorf("unexpected message %q", results[0].Message)
+	}
 }
 
 func TestVerify_NestedValid(t *testing.T) {
@@ -97,7 +107,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -137,7 +147,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -147,11 +157,15 @@ func GetOrders(db *gorm.DB) {
 	if results[0].Relation != "User.Profil.Address" {
 		t.Errorf("expected relation 'User.Profil.Address', got '%s'", results[0].Relation)
 	}
+	if got := results[0].Suggestion; len(got) != 1 || got[0] != "Profile" {
+		t.Errorf("expected suggestion [Profile] for the failing segment, got %v", got)
+	}
+	if !strings.HasSuffix(results[0].Message, `(did you mean "Profile"?)`) {
+		t.Errorf("unexpected message %q", results[0].Message)
+	}
 }
 
-func TestVerify_DynamicSkipped(t *testing.T) {
-	chains := loadAndCollect(t, map[string]string{
-		"main.go": `package main
+const dynamicFixture = `package main
 
 import "gorm.io/gorm"
 
@@ -163,14 +177,27 @@ func GetData(db *gorm.DB, field string) {
 	var users []User
 	db.Preload(field).Find(&users)
 }
-`,
-	})
-	results := Verify(chains)
+`
+
+func TestVerify_Dynamic(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{"main.go": dynamicFixture})
+	results := Verify(chains, Options{})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != "dynamic" {
+		t.Errorf("expected 'dynamic' for dynamic arg, got '%s'", results[0].Status)
+	}
+}
+
+func TestVerify_DynamicAsWarning(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{"main.go": dynamicFixture})
+	results := Verify(chains, Options{DynamicAsWarning: true})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
-	if results[0].Status != "skipped" {
-		t.Errorf("expected 'skipped' for dynamic arg, got '%s'", results[0].Status)
+	if results[0].Status != "warning" {
+		t.Errorf("expected 'warning' for dynamic arg, got '%s'", results[0].Status)
 	}
 }
 
@@ -205,7 +232,7 @@ type Order struct {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -214,6 +241,74 @@ type Order struct {
 	}
 }
 
+func TestVerify_SameNameModels(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/auth"
+	"testmod/billing"
+)
+
+func Load(db *gorm.DB) {
+	var authUsers []auth.User
+	db.Preload("Roles").Preload("Invoices").Find(&authUsers)
+
+	var billingUsers []billing.User
+	db.Preload("Invoices").Preload("Roles").Find(&billingUsers)
+}
+`,
+		"auth/auth.go": `package auth
+
+type Role struct {
+	Name string
+}
+
+type User struct {
+	ID    int64
+	Roles []Role
+}
+`,
+		"billing/billing.go": `package billing
+
+type Invoice struct {
+	Total int64
+}
+
+type User struct {
+	ID       int64
+	Invoices []Invoice
+}
+`,
+	})
+
+	results := Verify(chains, Options{Models: []string{"billing.User"}})
+	want := []struct{ relation, model, status string }{
+		{"Roles", "auth.User", "skipped"},
+		{"Invoices", "auth.User", "skipped"},
+		{"Invoices", "billing.User", "valid"},
+		{"Roles", "billing.User", "error"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Relation != w.relation || r.Model != w.model || r.Status != w.status {
+			t.Errorf("result %d = %s on %s: %s, want %s on %s: %s", i, r.Relation, r.Model, r.Status, w.relation, w.model, w.status)
+		}
+	}
+
+	// Unqualified, the allowlist matches both
+	for _, r := range Verify(chains, Options{Models: []string{"User"}}) {
+		if r.Status == "skipped" {
+			t.Errorf("%s on %s: expected the bare name to match", r.Relation, r.Model)
+		}
+	}
+}
+
 func TestVerify_EmbeddedStruct(t *testing.T) {
 	chains := loadAndCollect(t, map[string]string{
 		"main.go": `package main
@@ -240,7 +335,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -249,6 +344,131 @@ func GetOrders(db *gorm.DB) {
 	}
 }
 
+func TestVerify_TypeAliases(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	ID      int64
+	Profile Profile
+}
+
+type Account = User
+
+type Users = []User
+
+type Owner = *Account
+
+type Conn = *gorm.DB
+
+type Team struct {
+	Lead    Owner
+	Members Users
+}
+
+func GetAccounts(db *gorm.DB) {
+	var account Account
+	db.Preload("Profile").Find(&account)
+	db.Preload("Profil").Find(&account)
+
+	var users Users
+	db.Preload("Profile").Find(&users)
+
+	var teams []Team
+	db.Preload("Lead.Profile").Preload("Members.Profile").Find(&teams)
+}
+
+func GetVia(conn Conn) {
+	var account Account
+	conn.Preload("Profile").Find(&account)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []struct{ relation, model, status string }{
+		{"Profile", "main.User", "valid"},
+		{"Profil", "main.User", "error"},
+		{"Profile", "main.User", "valid"},
+		{"Lead.Profile", "main.Team", "valid"},
+		{"Members.Profile", "main.Team", "valid"},
+		{"Profile", "main.User", "valid"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Relation != w.relation || r.Model != w.model || r.Status != w.status {
+			t.Errorf("result %d = %s on %s: %s (%s), want %s on %s: %s", i, r.Relation, r.Model, r.Status, r.Message, w.relation, w.model, w.status)
+		}
+	}
+}
+
+func TestVerify_FailedSegment(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Product struct {
+	ID int64
+}
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	Profile Profile
+}
+
+type Order struct {
+	User    User
+	Product Product
+	Meta    map[string]string
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Items.Product").
+		Preload("User.Profil").
+		Preload("User.profile").
+		Preload("User..Profile").
+		Preload("Meta").
+		Preload("User.Profile").
+		Find(&orders)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := map[string]int{
+		"Items.Product": 0,
+		"User.Profil":   1,
+		"User.profile":  1,
+		"User..Profile": 1,
+		"Meta":          0,
+		"User.Profile":  -1,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for _, r := range results {
+		got := -1
+		if r.FailedSegment != nil {
+			got = *r.FailedSegment
+		}
+		if got != want[r.Relation] {
+			t.Errorf("%s (%s): FailedSegment = %d, want %d", r.Relation, r.Kind, got, want[r.Relation])
+		}
+	}
+}
+
 func TestVerify_ClauseAssociations(t *testing.T) {
 	chains := loadAndCollect(t, map[string]string{
 		"main.go": `package main
@@ -273,7 +493,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -295,15 +515,21 @@ type Order struct {
 func GetOrders(db *gorm.DB) {
 	var orders []Order
 	db.Preload("").Find(&orders)
+	db.Preload("  ").Find(&orders)
 }
 `,
 	})
-	results := Verify(chains)
-	if len(results) != 1 {
-		t.Fatalf("expected 1 result, got %d", len(results))
+	results := Verify(chains, Options{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
 	}
-	if results[0].Status != "error" {
-		t.Errorf("expected 'error' for empty relation, got '%s'", results[0].Status)
+	for _, r := range results {
+		if r.Status != "error" {
+			t.Errorf("expected 'error' for empty relation %q, got '%s'", r.Relation, r.Status)
+		}
+		if r.Message != "empty preload relation" {
+			t.Errorf("expected 'empty preload relation' message, got %q", r.Message)
+		}
 	}
 }
 
@@ -328,7 +554,7 @@ func GetOrders(db *gorm.DB) {
 }
 `,
 	})
-	results := Verify(chains)
+	results := Verify(chains, Options{})
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -339,3 +565,1486 @@ func GetOrders(db *gorm.DB) {
 		t.Errorf("expected non-zero Line, got 0")
 	}
 }
+
+const hasManyFixture = `package main
+
+import "gorm.io/gorm"
+
+type Tag struct {
+	Name string
+}
+
+type Comment struct {
+	Tags []Tag
+}
+
+type Post struct {
+	Comments []Comment
+}
+
+type Author struct {
+	Posts []Post
+}
+
+type Country struct {
+	Name string
+}
+
+type City struct {
+	Country Country
+}
+
+type Address struct {
+	City City
+}
+
+type Person struct {
+	Address Address
+}
+
+func Load(db *gorm.DB) {
+	var authors []Author
+	db.Preload("Posts.Comments.Tags").Find(&authors)
+	var people []Person
+	db.Preload("Address.City.Country").Find(&people)
+}
+`
+
+func TestVerify_HasManyHops_Warns(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{"main.go": hasManyFixture})
+	results := Verify(chains, Options{MaxHasManyHops: 2})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != "warning" {
+		t.Errorf("expected 'warning' for three has-many hops, got '%s'", results[0].Status)
+	}
+	if results[0].Message == "" {
+		t.Error("expected a message explaining the warning")
+	}
+	if results[1].Status != "valid" {
+		t.Errorf("expected 'valid' for three has-one hops, got '%s'", results[1].Status)
+	}
+}
+
+func TestVerify_HasManyHops_DisabledByDefault(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{"main.go": hasManyFixture})
+	for _, r := range Verify(chains, Options{}) {
+		if r.Status != "valid" {
+			t.Errorf("%s: expected 'valid' with the rule disabled, got '%s'", r.Relation, r.Status)
+		}
+	}
+}
+
+func TestVerify_RangeOverLiteral(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Post struct {
+	ID int64
+}
+
+type Author struct {
+	Posts    []Post
+	Comments []Post
+}
+
+func GetAuthors(db *gorm.DB, names []string) {
+	var authors []Author
+	for _, rel := range []string{
+		"Posts",
+		"Comment",
+	} {
+		db.Preload(rel).Find(&authors)
+	}
+	for _, rel := range names {
+		db.Preload(rel).Find(&authors)
+	}
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	want := []struct {
+		relation, status string
+		line             int
+	}{
+		{"Posts", "valid", 17},
+		{"Comment", "error", 18},
+		{"(dynamic)", "dynamic", 23},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Relation != w.relation || r.Status != w.status || r.Line != w.line {
+			t.Errorf("result %d: expected %s/%s at line %d, got %s/%s at line %d",
+				i, w.relation, w.status, w.line, r.Relation, r.Status, r.Line)
+		}
+	}
+}
+
+func TestVerify_MalformedPath(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	Profile Profile
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("User..Profile").Find(&users)
+	db.Preload(".User").Find(&users)
+	db.Preload("User.").Find(&users)
+	db.Preload("...").Find(&users)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Status != "error" {
+			t.Errorf("%q: expected 'error', got '%s'", r.Relation, r.Status)
+		}
+		if !strings.HasPrefix(r.Message, "malformed preload path") {
+			t.Errorf("%q: expected malformed path message, got %q", r.Relation, r.Message)
+		}
+	}
+}
+
+func TestVerify_CaseMismatch(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type QrCode struct {
+	ID int64
+}
+
+type Machine struct {
+	ID        int64
+	Id        int64
+	MachineQr QrCode
+}
+
+type Staff struct {
+	Machine Machine
+}
+
+func GetStaff(db *gorm.DB) {
+	var staff []Staff
+	db.Preload("machine").Find(&staff)
+	db.Preload("Machine.machineQr").Find(&staff)
+	db.Preload("machine.machineqr").Find(&staff)
+	db.Preload("Machine.MachineQR").Find(&staff)
+	db.Preload("machine.owner").Find(&staff)
+	db.Preload("Machine.id").Find(&staff)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	fixes := []string{"Machine", "Machine.MachineQr", "Machine.MachineQr", "Machine.MachineQr"}
+	for i, want := range fixes {
+		r := results[i]
+		if r.Status != "error" || r.Kind != "case-mismatch" {
+			t.Errorf("%q: expected case-mismatch error, got %s/%s", r.Relation, r.Status, r.Kind)
+		}
+		if len(r.Suggestion) != 1 || r.Suggestion[0] != want {
+			t.Errorf("%q: expected suggestion %q, got %v", r.Relation, want, r.Suggestion)
+		}
+		if !strings.HasSuffix(r.Message, fmt.Sprintf("(did you mean %q?)", want)) {
+			t.Errorf("%q: unexpected message %q", r.Relation, r.Message)
+		}
+	}
+	// A segment that folds to nothing, or to several fields, is still malformed
+	for _, r := range results[4:] {
+		if r.Kind != "malformed-path" {
+			t.Errorf("%q: expected malformed-path, got %s", r.Relation, r.Kind)
+		}
+	}
+}
+
+func TestVerify_ModelAllowlist(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Customer struct {
+	ID int64
+}
+
+type Invoice struct {
+	Customer Customer
+}
+
+type TripLeg struct {
+	Customer Customer
+}
+
+type Note struct {
+	Customer Customer
+}
+
+func Load(db *gorm.DB, dest interface{}) {
+	var invoices []Invoice
+	var legs []TripLeg
+	var notes []Note
+	db.Preload("Custmer").Find(&invoices)
+	db.Preload("Custmer").Find(&legs)
+	db.Preload("Custmer").Find(&notes)
+	db.Preload("Customer").Find(dest)
+}
+`,
+	})
+	results := Verify(chains, Options{Models: []string{"Invoice", "Trip*"}})
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for _, r := range results[:2] {
+		if r.Status != "error" {
+			t.Errorf("%s on %s: allowlisted typo must still fail, got '%s'", r.Relation, r.Model, r.Status)
+		}
+	}
+	for _, r := range results[2:] {
+		if r.Status != "skipped" || r.Kind != "not-allowlisted" {
+			t.Errorf("%s on %s: expected skipped, got %s/%s", r.Relation, r.Model, r.Status, r.Kind)
+		}
+	}
+
+	qualified := Verify(chains, Options{Models: []string{"main.Note"}})
+	if qualified[2].Status != "error" || qualified[0].Status != "skipped" {
+		t.Errorf("qualified pattern: expected Note verified and Invoice skipped, got %s and %s",
+			qualified[2].Status, qualified[0].Status)
+	}
+}
+
+func TestVerify_Suppressed(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+func GetUsers(db *gorm.DB, rel string) {
+	var users []User
+	db.Preload("Orders").Find(&users) //gpc:ignore wrong-model known issue
+	db.Preload(rel).Find(&users)      //gpc:ignore
+	db.Preload("ID").Find(&users)     //gpc:ignore
+	db.Preload("Orders").Find(&users)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []string{"suppressed", "suppressed", "valid", "error"}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		if results[i].Status != w {
+			t.Errorf("line %d: expected '%s', got '%s'", results[i].Line, w, results[i].Status)
+		}
+	}
+	if results[0].Kind != "not-found" {
+		t.Errorf("suppressed result should keep its kind, got %q", results[0].Kind)
+	}
+}
+
+func TestVerify_DuplicatePreload(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Staff struct {
+	ID int64
+}
+
+type Machine struct {
+	Staff Staff
+}
+
+func GetMachines(db *gorm.DB) {
+	var machines []Machine
+	db.Preload("Staff").
+		Preload("Staff").
+		Find(&machines)
+
+	db.Preload("Staff").Find(&machines)
+	db.Where("id > ?", 1).Preload("Staff").Find(&machines)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if results[0].Status != "valid" {
+		t.Errorf("expected first occurrence 'valid', got '%s'", results[0].Status)
+	}
+	dup := results[1]
+	if dup.Status != "warning" || dup.Kind != "duplicate-preload" {
+		t.Errorf("expected duplicate-preload warning, got %s/%s", dup.Status, dup.Kind)
+	}
+	if dup.Message != "duplicate preload of Staff (lines 15 and 16)" {
+		t.Errorf("unexpected message %q", dup.Message)
+	}
+	for _, r := range results[2:] {
+		if r.Status != "valid" {
+			t.Errorf("line %d: separate chains must not be flagged, got '%s'", r.Line, r.Status)
+		}
+	}
+
+	for _, r := range Verify(chains, Options{AllowDuplicates: true}) {
+		if r.Status != "valid" {
+			t.Errorf("line %d: expected no duplicate warning with AllowDuplicates, got %s/%s", r.Line, r.Status, r.Kind)
+		}
+	}
+}
+
+func TestVerify_RedundantParent(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Product struct {
+	ID int64
+}
+
+type Item struct {
+	Product Product
+}
+
+type Order struct {
+	Items []Item
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Items").Preload("Items.Product").Find(&orders)
+	db.Preload("Items", "qty > ?", 0).Preload("Items.Product").Find(&orders)
+	db.Preload("Items").Preload("Items.Prodcut").Find(&orders)
+}
+`,
+	})
+
+	for _, r := range Verify(chains, Options{}) {
+		if r.Status == "info" {
+			t.Errorf("line %d: redundant parents must be opt-in, got 'info' for %s", r.Line, r.Relation)
+		}
+	}
+
+	results := Verify(chains, Options{RedundantParents: true})
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	if results[0].Status != "info" || results[0].Kind != "redundant-preload" {
+		t.Errorf("expected redundant-preload info, got %s/%s", results[0].Status, results[0].Kind)
+	}
+	if results[0].Message != "Items is already loaded by Items.Product (line 19)" {
+		t.Errorf("unexpected message %q", results[0].Message)
+	}
+	if results[2].Status != "valid" {
+		t.Errorf("conditional parent must not be flagged, got '%s'", results[2].Status)
+	}
+	if results[4].Status != "valid" {
+		t.Errorf("parent of an invalid path must not be flagged, got '%s'", results[4].Status)
+	}
+}
+
+func TestVerify_OptionIntents(t *testing.T) {
+	chains := loadAndCollectWith(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Product struct {
+	ID int64
+}
+
+type Item struct {
+	Product Product
+}
+
+type Staff struct {
+	ID int64
+}
+
+type Invoice struct {
+	Items []Item
+	Staff Staff
+}
+
+type QueryOpts struct {
+	Preloads []string
+}
+
+func runQuery(db *gorm.DB, opts QueryOpts, dest any) {
+	for _, p := range opts.Preloads {
+		db = db.Preload(p)
+	}
+	db.Find(dest)
+}
+
+func ListInvoices(db *gorm.DB) {
+	opts := QueryOpts{Preloads: []string{"Items.Product", "Staf"}}
+	var invoices []Invoice
+	runQuery(db, opts, &invoices)
+}
+
+func DefaultOpts() QueryOpts {
+	return QueryOpts{Preloads: []string{"Staff"}}
+}
+`,
+	}, collector.Options{OptionFields: []string{"Preloads"}})
+
+	results := Verify(chains, Options{})
+	want := []struct{ relation, status string }{
+		{"(dynamic)", "dynamic"}, // the runner's own loop
+		{"Items.Product", "valid"},
+		{"Staf", "error"},
+		{"Staff", "dynamic"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d: %+v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		if results[i].Relation != w.relation || results[i].Status != w.status {
+			t.Errorf("result %d: expected %s/%s, got %s/%s", i, w.relation, w.status, results[i].Relation, results[i].Status)
+		}
+	}
+	if results[1].Model != "main.Invoice" {
+		t.Errorf("expected intents anchored to main.Invoice, got %s", results[1].Model)
+	}
+}
+
+func TestVerify_InterfaceSegmentUnknown(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Owner interface {
+	OwnerName() string
+}
+
+type Pet struct {
+	Owner Owner
+}
+
+func GetPets(db *gorm.DB) {
+	var pets []Pet
+	db.Preload("Owner.Profile").Find(&pets)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Status != "unknown" {
+		t.Errorf("expected 'unknown', got '%s'", results[0].Status)
+	}
+	if results[0].Message != "cannot traverse interface-typed field Owner" {
+		t.Errorf("unexpected message %q", results[0].Message)
+	}
+}
+
+func TestVerify_NotPreloadable(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Owner interface {
+	OwnerName() string
+}
+
+type Item struct {
+	Name string
+}
+
+type Metadata map[string]any
+
+type Hook func(*Pet)
+
+type Pet struct {
+	Owner    Owner
+	Tags     map[string]Item
+	OnSave   func() error
+	Updates  chan Item
+	Settings *map[string]string
+	Meta     Metadata
+	Any      interface{}
+	Hooks    []Hook
+	Items    []Item
+}
+
+func GetPets(db *gorm.DB) {
+	var pets []Pet
+	db.Preload("Owner").
+		Preload("Tags").
+		Preload("Tags.Name").
+		Preload("OnSave").
+		Preload("Updates").
+		Preload("Settings").
+		Preload("Meta").
+		Preload("Any").
+		Preload("Hooks").
+		Preload("Items").
+		Find(&pets)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := map[string]string{
+		"Owner":     "Owner is not a preloadable relation (interface field of type Owner)",
+		"Tags":      "Tags is not a preloadable relation (map field of type map[string]Item)",
+		"Tags.Name": "Tags is not a preloadable relation (map field of type map[string]Item)",
+		"OnSave":    "OnSave is not a preloadable relation (func field of type func() error)",
+		"Updates":   "Updates is not a preloadable relation (chan field of type chan Item)",
+		"Settings":  "Settings is not a preloadable relation (map field of type *map[string]string)",
+		"Meta":      "Meta is not a preloadable relation (map field of type Metadata)",
+		"Any":       "Any is not a preloadable relation (interface field of type interface{})",
+		"Hooks":     "Hooks is not a preloadable relation (func field of type []Hook)",
+	}
+	if len(results) != len(want)+1 {
+		t.Fatalf("expected %d results, got %d", len(want)+1, len(results))
+	}
+	for _, r := range results {
+		msg, bad := want[r.Relation]
+		if !bad {
+			if r.Status != "valid" {
+				t.Errorf("%s: expected valid, got %s (%s)", r.Relation, r.Status, r.Message)
+			}
+			continue
+		}
+		if r.Status != "error" || r.Kind != "not-preloadable" || r.Message != msg {
+			t.Errorf("%s: got %s/%s %q, want error/not-preloadable %q", r.Relation, r.Status, r.Kind, r.Message, msg)
+		}
+	}
+}
+
+func TestVerify_FinisherInIfInit(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Org struct {
+	ID int64
+}
+
+type Role struct {
+	ID    int64
+	OrgID int64
+	Org   Org
+}
+
+type User struct {
+	ID     int64
+	RoleID int64
+}
+
+func ExampleStaffLogin(db *gorm.DB, user User) error {
+	var role Role
+	if err := db.Preload("Org").First(&role, user.RoleID).Error; err != nil {
+		return errors.New("role not found")
+	}
+	return nil
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d: %+v", len(results), results)
+	}
+	if results[0].Model != "main.Role" {
+		t.Errorf("expected model main.Role, got %s", results[0].Model)
+	}
+	if results[0].Status != "valid" {
+		t.Errorf("expected 'valid', got '%s': %s", results[0].Status, results[0].Message)
+	}
+}
+
+func TestVerify_IgnoreAndSeverity(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Item struct {
+	ID int64
+}
+
+type Order struct {
+	Items []Item
+}
+
+type LegacyOrder struct {
+	ID int64
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Itms").Find(&orders)
+	db.Preload("AuditLog").Find(&orders)
+	db.Preload("Items").Preload("Items").Find(&orders)
+
+	var legacy []LegacyOrder
+	db.Preload("Customer").Find(&legacy)
+}
+`,
+	})
+	results := Verify(chains, Options{
+		IgnoreModels:    []string{"Legacy*"},
+		IgnoreRelations: []string{"Audit*"},
+		Severity:        map[string]string{"duplicate-preload": "error", "not-found": "warning"},
+	})
+	want := []struct{ relation, status string }{
+		{"Itms", "warning"},
+		{"AuditLog", "suppressed"},
+		{"Items", "valid"},
+		{"Items", "error"},
+		{"Customer", "suppressed"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d: %+v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		if results[i].Relation != w.relation || results[i].Status != w.status {
+			t.Errorf("result %d: expected %s/%s, got %s/%s", i, w.relation, w.status, results[i].Relation, results[i].Status)
+		}
+	}
+	if results[4].Kind != "not-found" {
+		t.Errorf("expected suppressed result to keep its kind, got %q", results[4].Kind)
+	}
+}
+
+func TestVerify_Many2ManyChain(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Permission struct {
+	ID   int64
+	Code string
+}
+
+type Role struct {
+	ID          int64
+	Name        string
+	Permissions []*Permission ` + "`gorm:\"many2many:role_permissions\"`" + `
+}
+
+type Staff struct {
+	ID    int64
+	Roles []Role ` + "`gorm:\"many2many:staff_roles\"`" + `
+}
+
+func GetStaff(db *gorm.DB) {
+	var staff []Staff
+	db.Preload("Roles").Find(&staff)
+	db.Preload("Roles.Permissions").Find(&staff)
+	db.Preload("Roles.Permission").Find(&staff)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []struct{ relation, status string }{
+		{"Roles", "valid"},
+		{"Roles.Permissions", "valid"},
+		{"Roles.Permission", "error"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d: %+v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		if results[i].Relation != w.relation || results[i].Status != w.status {
+			t.Errorf("result %d: expected %s/%s, got %s/%s (%s)", i, w.relation, w.status, results[i].Relation, results[i].Status, results[i].Message)
+		}
+	}
+	// The typo is looked up in the slice element type, Role
+	if got := results[2].Suggestion; len(got) != 1 || got[0] != "Permissions" {
+		t.Errorf("expected suggestion Permissions, got %v", got)
+	}
+}
+
+func TestVerify_Variable(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+type Response struct {
+	Items []Order
+}
+
+func GetOrders(db *gorm.DB, resp *Response) {
+	var orders []Order
+	db.Where("id > ?", 0).
+		Preload("User").
+		Order("id").
+		Find(&orders)
+	db.Preload("User").Find(&resp.Items)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for i, want := range []string{"orders", "resp.Items"} {
+		if results[i].Variable != want {
+			t.Errorf("result %d: Variable = %q, want %q", i, results[i].Variable, want)
+		}
+	}
+}
+
+func TestVerify_Explain(t *testing.T) {
+	chains := loadAndCollectWith(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+type QueryOpts struct {
+	Preloads []string
+}
+
+func run(db *gorm.DB, opts QueryOpts, dest any) {}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+
+	q := db.Preload("User")
+	q.First(&orders)
+
+	var n int
+	db.Preload("User").Find(&n)
+
+	run(db, QueryOpts{Preloads: []string{"User"}}, &orders)
+	_ = QueryOpts{Preloads: []string{"User"}}
+}
+`,
+	}, collector.Options{OptionFields: []string{"Preloads"}})
+
+	want := []string{
+		"Find(&orders) on line 21 (same chain): model main.Order from type *[]Order",
+		"First(&orders) on line 24 (query variable): model main.Order from type *[]Order",
+		"Find(&n) on line 27 (same chain): no model struct in type *int",
+		"run(&orders) on line 29 (option field): model main.Order from type *[]Order",
+		"no query receives the relation names (option field)",
+	}
+	results := Verify(chains, Options{Explain: true})
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, r := range results {
+		if r.Explain != want[i] {
+			t.Errorf("result %d: Explain = %q, want %q", i, r.Explain, want[i])
+		}
+	}
+	if r := Verify(chains, Options{}); r[0].Explain != "" {
+		t.Errorf("expected no trace without Explain, got %q", r[0].Explain)
+	}
+}
+
+func TestVerify_ScalarTypes(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"money/money.go": `package money
+
+type Amount struct {
+	Cents    int64
+	Currency string
+}
+`,
+		"main.go": `package main
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"testmod/money"
+)
+
+type Item struct {
+	CreatedAt time.Time
+}
+
+type Invoice struct {
+	CreatedAt time.Time
+	PaidAt    *time.Time
+	Note      sql.NullString
+	Ref       sql.Null[int64]
+	DeletedAt gorm.DeletedAt
+	Total     money.Amount
+	Items     []Item
+}
+
+func GetInvoices(db *gorm.DB) {
+	var invoices []Invoice
+	db.Preload("CreatedAt").
+		Preload("PaidAt").
+		Preload("Note").
+		Preload("Ref").
+		Preload("DeletedAt").
+		Preload("CreatedAt.Location").
+		Preload("Total").
+		Preload("Items").
+		Find(&invoices)
+}
+`,
+	})
+	want := map[string]string{
+		"CreatedAt":          "CreatedAt is not a preloadable relation (scalar field of type time.Time)",
+		"PaidAt":             "PaidAt is not a preloadable relation (scalar field of type *time.Time)",
+		"Note":               "Note is not a preloadable relation (scalar field of type sql.NullString)",
+		"Ref":                "Ref is not a preloadable relation (scalar field of type sql.Null[int64])",
+		"DeletedAt":          "DeletedAt is not a preloadable relation (scalar field of type gorm.DeletedAt)",
+		"CreatedAt.Location": "CreatedAt is not a preloadable relation (scalar field of type time.Time)",
+		"Total":              "Total is not a preloadable relation (scalar field of type money.Amount)",
+	}
+
+	results := Verify(chains, Options{ScalarTypes: []string{"testmod/money.Amount"}})
+	if len(results) != len(want)+1 {
+		t.Fatalf("expected %d results, got %d", len(want)+1, len(results))
+	}
+	for _, r := range results {
+		msg, bad := want[r.Relation]
+		if !bad {
+			if r.Status != "valid" {
+				t.Errorf("%s: expected valid, got %s (%s)", r.Relation, r.Status, r.Message)
+			}
+			continue
+		}
+		if r.Status != "error" || r.Kind != "not-preloadable" || r.Message != msg {
+			t.Errorf("%s: got %s/%s %q, want error/not-preloadable %q", r.Relation, r.Status, r.Kind, r.Message, msg)
+		}
+	}
+
+	// Without the option, a struct from the module is a relation
+	for _, r := range Verify(chains, Options{}) {
+		if r.Relation == "Total" && r.Status != "valid" {
+			t.Errorf("Total: expected valid without ScalarTypes, got %s (%s)", r.Status, r.Message)
+		}
+	}
+}
+
+func TestVerify_SelectColumns(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Audit struct {
+	CreatedBy string
+}
+
+type Staff struct {
+	ID        int64
+	FullName  string
+	HTTPPort  int
+	Email     string ` + "`gorm:\"column:mail\"`" + `
+	Secret    string ` + "`gorm:\"-\"`" + `
+	Audit     Audit  ` + "`gorm:\"embedded;embeddedPrefix:audit_\"`" + `
+	gorm.Model
+}
+
+type Machine struct {
+	Staff Staff
+}
+
+func GetMachines(db *gorm.DB) {
+	var machines []Machine
+	db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, full_name, http_port, mail, audit_created_by, created_at")
+	}).Find(&machines)
+	db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, ful_name")
+	}).Find(&machines)
+	db.Preload("Staff", func(db *gorm.DB) *gorm.DB {
+		return db.Select("secret")
+	}).Find(&machines)
+}
+`,
+	})
+
+	for _, r := range Verify(chains, Options{}) {
+		if r.Status != "valid" {
+			t.Errorf("%s:%d: expected valid without CheckSelectColumns, got %s (%s)", r.Relation, r.Line, r.Status, r.Message)
+		}
+	}
+
+	// Unknown columns are reported on their own; the preloads stay valid
+	results := Verify(chains, Options{CheckSelectColumns: true})
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	var columns []models.PreloadResult
+	for _, r := range results {
+		if r.Kind == "unknown-column" {
+			columns = append(columns, r)
+		} else if r.Status != "valid" {
+			t.Errorf("%s:%d: expected the preload to be valid, got %s (%s)", r.Relation, r.Line, r.Status, r.Message)
+		}
+	}
+	want := []struct {
+		line, column int
+		msg          string
+	}{
+		{29, 25, `column "ful_name" selected for Staff not found in main.Staff (did you mean "full_name"?)`},
+		{32, 21, `column "secret" selected for Staff not found in main.Staff`},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("expected %d unknown-column results, got %+v", len(want), columns)
+	}
+	for i, w := range want {
+		r := columns[i]
+		if r.Status != "error" || r.Message != w.msg || r.Line != w.line || r.Column != w.column {
+			t.Errorf("column %d: got %s %q at %d:%d, want error %q at %d:%d",
+				i, r.Status, r.Message, r.Line, r.Column, w.msg, w.line, w.column)
+		}
+	}
+}
+
+func TestVerify_SelectColumnsKeepPreloadResult(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Item struct {
+	ID   int64
+	Name string
+}
+
+type Order struct {
+	ID    int64
+	Items []Item
+}
+
+type User struct {
+	ID     int64
+	Orders []Order
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Orders.Items", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, nme")
+	}).Find(&users)
+}
+`,
+	})
+
+	results := Verify(chains, Options{CheckSelectColumns: true, MaxHasManyHops: 1})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if r := results[0]; r.Status != "warning" || r.Kind != "has-many-depth" || r.Line != 22 || r.Column != 13 {
+		t.Errorf("expected the has-many-depth warning at 22:13, got %s/%s at %d:%d", r.Status, r.Kind, r.Line, r.Column)
+	}
+	if r := results[1]; r.Status != "error" || r.Kind != "unknown-column" || r.Line != 23 || r.Column != 25 {
+		t.Errorf("expected the unknown-column error at 23:25, got %s/%s at %d:%d", r.Status, r.Kind, r.Line, r.Column)
+	}
+}
+
+func TestToDBName(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"FullName":   "full_name",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"CreatedAt":  "created_at",
+	}
+	for in, want := range tests {
+		if got := toDBName(in); got != want {
+			t.Errorf("toDBName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestVerify_ColumnName(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Post struct {
+	CreatedBy User
+	Author    User
+}
+
+type Blog struct {
+	LatestPost Post
+	UserID     int64
+}
+
+func GetBlogs(db *gorm.DB) {
+	var blogs []Blog
+	db.Preload("latest_post").
+		Preload("LatestPost.created_by").
+		Preload("latest_post.author").
+		Preload("user_id").
+		Preload("LatestPost.CreatedBy").
+		Find(&blogs)
+}
+`,
+	})
+	want := []struct {
+		msg        string
+		suggestion string
+		segment    int
+	}{
+		{`preload expects the Go field name "LatestPost", not the column "latest_post"`, "LatestPost", 0},
+		{`preload expects the Go field name "CreatedBy", not the column "created_by"`, "LatestPost.CreatedBy", 1},
+		{`preload expects the Go field name "LatestPost", not the column "latest_post"`, "LatestPost.Author", 0},
+		{`preload expects the Go field name "UserID", not the column "user_id"`, "UserID", 0},
+	}
+	results := Verify(chains, Options{})
+	if len(results) != len(want)+1 {
+		t.Fatalf("expected %d results, got %d", len(want)+1, len(results))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Status != "error" || r.Kind != "column-name" || r.Message != w.msg {
+			t.Errorf("%s: got %s/%s %q, want error/column-name %q", r.Relation, r.Status, r.Kind, r.Message, w.msg)
+		}
+		if len(r.Suggestion) != 1 || r.Suggestion[0] != w.suggestion {
+			t.Errorf("%s: Suggestion = %v, want [%s]", r.Relation, r.Suggestion, w.suggestion)
+		}
+		if r.FailedSegment == nil || *r.FailedSegment != w.segment {
+			t.Errorf("%s: FailedSegment = %v, want %d", r.Relation, r.FailedSegment, w.segment)
+		}
+	}
+	if r := results[len(want)]; r.Status != "valid" {
+		t.Errorf("%s: expected valid, got %s (%s)", r.Relation, r.Status, r.Message)
+	}
+}
+
+func TestVerify_Association(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Role struct {
+	ID int64
+}
+
+type Staff struct {
+	ID    int64
+	Roles []Role
+}
+
+func GetRoles(db *gorm.DB) {
+	var staff Staff
+	var roles []Role
+	db.Model(&staff).Association("Roles").Find(&roles)
+	db.Model(&staff).Association("Role").Find(&roles)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != "valid" || results[0].Model != "main.Staff" {
+		t.Errorf("Roles: expected valid on main.Staff, got %s on %s", results[0].Status, results[0].Model)
+	}
+	r := results[1]
+	if r.Status != "error" || r.Kind != "not-found" || len(r.Suggestion) != 1 || r.Suggestion[0] != "Roles" {
+		t.Errorf("Role: expected not-found suggesting Roles, got %s/%s %v", r.Status, r.Kind, r.Suggestion)
+	}
+}
+
+func TestVerify_SliceTraversal(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Option struct {
+	ID int64
+}
+
+type Variant struct {
+	ID      int64
+	Options []Option
+}
+
+type Product struct {
+	ID       int64
+	Variants []*Variant
+}
+
+type InvoiceItem struct {
+	ID      int64
+	Product *Product
+}
+
+type Invoice struct {
+	ID    int64
+	Items []InvoiceItem
+}
+
+func GetInvoices(db *gorm.DB) {
+	var invoices []*Invoice
+	db.Preload("Items.Product").
+		Preload("Items.Product.Variants").
+		Preload("Items.Product.Variants.Options").
+		Preload("Items.Product.Variants.Optons").
+		Find(&invoices)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for _, r := range results[:3] {
+		if r.Status != "valid" {
+			t.Errorf("%s: expected valid through slice fields, got %s (%s)", r.Relation, r.Status, r.Message)
+		}
+	}
+	if r := results[3]; r.Status != "error" || r.FailedSegment == nil || *r.FailedSegment != 3 {
+		t.Errorf("%s: expected an error at segment 3, got %s (%s)", r.Relation, r.Status, r.Message)
+	}
+}
+
+func TestVerify_DestinationNotModel(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+func Query(db *gorm.DB, tx *gorm.DB, dest any, out interface{}) {
+	var rows []map[string]any
+	db.Preload("User").Find(tx)
+	db.Preload("User").Find(&rows)
+	db.Preload("User").Find(dest)
+	db.Preload("User").Find(out)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []string{
+		"destination is not a model struct (*gorm.DB)",
+		"destination is not a model struct (*[]map[string]any)",
+		"destination is an interface (any); its model is only known at run time",
+		"destination is an interface (interface{}); its model is only known at run time",
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Status != "unknown" || r.Kind != "unresolved-model" || r.Message != w {
+			t.Errorf("result %d: got %s/%s %q, want unknown/unresolved-model %q", i, r.Status, r.Kind, r.Message, w)
+		}
+	}
+}
+
+func TestVerify_ScanProjection(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Customer struct {
+	ID int64
+}
+
+type Invoice struct {
+	gorm.Model
+	Customer Customer
+}
+
+type InvoiceRow struct {
+	ID        int64
+	Total     float64
+	CreatedAt time.Time
+}
+
+type Ledger struct {
+	ID int64
+}
+
+func (Ledger) TableName() string { return "ledgers" }
+
+func Report(db *gorm.DB) {
+	var rows []InvoiceRow
+	db.Model(&Invoice{}).Preload("Customer").Scan(&rows)
+	db.Model(&Invoice{}).Preload("Customer").Find(&rows)
+
+	var ledgers []Ledger
+	db.Preload("Customer").Scan(&ledgers)
+
+	var invoices []Invoice
+	db.Preload("Custmer").Scan(&invoices)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []struct{ status, kind string }{
+		{"skipped", "scan-projection"},
+		{"error", "not-found"},
+		{"error", "not-found"},
+		{"error", "not-found"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		if r := results[i]; r.Status != w.status || r.Kind != w.kind {
+			t.Errorf("result %d (%s on %s): got %s/%s, want %s/%s", i, r.Relation, r.Model, r.Status, r.Kind, w.status, w.kind)
+		}
+	}
+}
+
+func TestVerify_RelationType(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID      int64
+	Profile *Profile
+}
+
+type Profile struct {
+	ID     int64
+	UserID int64
+}
+
+type Tag struct {
+	ID int64
+}
+
+type Item struct {
+	ID int64
+}
+
+type Order struct {
+	ID     int64
+	UserID int64
+	User   User
+	Items  []Item
+	Tags   []*Tag ` + "`gorm:\"many2many:order_tags\"`" + `
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Preload("User.Profile").Preload("Items").Preload("Tags").Preload("Itms").Find(&orders)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []struct{ relation, target string }{
+		{"belongs_to", "main.User"},
+		{"has_one", "main.Profile"},
+		{"has_many", "main.Item"},
+		{"many2many", "main.Tag"},
+		{"", ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		if r := results[i]; r.RelationType != w.relation || r.TargetModel != w.target {
+			t.Errorf("%s: got %q to %q, want %q to %q", r.Relation, r.RelationType, r.TargetModel, w.relation, w.target)
+		}
+	}
+}
+
+func TestVerify_RelationKinds(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type Machine struct {
+	ID int64
+}
+
+type Product struct {
+	ID int64
+}
+
+type InvoiceItem struct {
+	ID        int64
+	InvoiceID int64
+	ProductID int64
+	Product   Product
+}
+
+type Receipt struct {
+	ID        int64
+	InvoiceID int64
+}
+
+type Payment struct {
+	ID     int64
+	BillID int64
+}
+
+type User struct {
+	ID int64
+}
+
+type Invoice struct {
+	gorm.Model
+	MachineID  int64
+	Machine    Machine
+	Items      []InvoiceItem
+	Receipt    *Receipt
+	Payment    Payment ` + "`gorm:\"foreignKey:BillID\"`" + `
+	ApprovedBy int64
+	Approver   User ` + "`gorm:\"foreignKey:ApprovedBy\"`" + `
+	ParentID   *uint
+	Parent     *Invoice
+}
+
+func List(db *gorm.DB) {
+	var invoices []Invoice
+	db.Preload("Machine").
+		Preload("Items.Product").
+		Preload("Receipt").
+		Preload("Payment").
+		Preload("Approver").
+		Preload("Parent").
+		Find(&invoices)
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := map[string]string{
+		"Machine":       "belongs_to",
+		"Items.Product": "belongs_to",
+		"Receipt":       "has_one",
+		"Payment":       "has_one",
+		"Approver":      "belongs_to",
+		"Parent":        "belongs_to",
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for _, r := range results {
+		if r.RelationType != want[r.Relation] {
+			t.Errorf("%s: got %q, want %q", r.Relation, r.RelationType, want[r.Relation])
+		}
+	}
+
+	// The walk classifies the last segment; Items alone is has-many
+	m := resolveModel(chains[0])
+	if got := m.walk("Items", nil).relation; got != "has_many" {
+		t.Errorf("Items: got %q, want has_many", got)
+	}
+}
+
+func TestVerify_MultiAssign(t *testing.T) {
+	chains := loadAndCollect(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func load() ([]Order, error) { return nil, errors.New("unused") }
+
+func GetOrders(db *gorm.DB) error {
+	orders, err := load()
+	if err != nil {
+		return err
+	}
+	db.Where("id > ?", 0).Preload("User").Find(&orders)
+
+	q, dest := db.Preload("Usr"), &orders
+	q.Find(dest)
+
+	var first, rest []Order
+	first, rest = orders[:1], orders[1:]
+	db.Preload("User").Find(&rest)
+	return db.Preload("User").Find(&first).Error
+}
+`,
+	})
+	results := Verify(chains, Options{})
+	want := []struct{ relation, variable, status string }{
+		{"User", "orders", "valid"},
+		{"Usr", "dest", "error"},
+		{"User", "rest", "valid"},
+		{"User", "first", "valid"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), results)
+	}
+	for i, w := range want {
+		if r := results[i]; r.Relation != w.relation || r.Variable != w.variable || r.Status != w.status || r.Model != "main.Order" {
+			t.Errorf("result %d: got %s into %s on %s: %s, want %s into %s on main.Order: %s", i, r.Relation, r.Variable, r.Model, r.Status, w.relation, w.variable, w.status)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package relations
 
 import (
+	"go/token"
 	"go/types"
 	"strings"
 )
@@ -12,31 +13,271 @@ import (
 //   - ok=false: failedAt = index of the first segment that didn't resolve,
 //     parent = the named struct type the failing segment was looked up in
 //     (nil when the segment's parent is an anonymous struct or unknown)
+//
+// opaque marks a walk stopped at failedAt because that segment is an
+// interface-typed field: the path can't be followed statically, which is
+// not the same as it being wrong.
+//
+// unpreloadable names the kind of type ("scalar", "map", "func", "chan" or
+// "interface") of the field at failedAt when GORM can never load it as a
+// relation, and fieldType spells that field's type ("map[string]Item"). An
+// interface only counts as the last segment; earlier it is opaque instead.
+//
+// suggestions holds did-you-mean candidates for a segment that wasn't
+// found among its parent's fields.
+//
+// corrected is set when a segment only matched a field case-insensitively
+// ("user" for User): it is the path with every such segment rewritten to
+// the field's exact name. Resolution continues through the corrected field.
+//
+// column is set when a segment only matched a field by its snake_case
+// column name ("created_by" for CreatedBy): it is the first such segment,
+// at columnAt, and columnField the field it names. corrected is set too.
+//
+// hasMany counts the slice/array-typed (has-many) segments traversed before
+// the walk stopped; each one multiplies the rows GORM loads.
+//
+// target is the model the last segment loads, when the walk got there and
+// the field unwraps to a struct, and relation the kind of relation that
+// field is (see relationType).
 type walkResult struct {
-	ok       bool
-	failedAt int
-	parent   *types.Named
+	ok            bool
+	failedAt      int
+	parent        *types.Named
+	opaque        bool
+	unpreloadable string
+	fieldType     string
+	suggestions   []string
+	corrected     string
+	column        string
+	columnField   string
+	columnAt      int
+	hasMany       int
+	target        *model
+	relation      string
 }
 
 // walk traverses a dotted relation path through the model's struct fields,
-// descending one segment at a time.
-func (m *model) walk(path string) walkResult {
+// descending one segment at a time. scalars holds extra scalar types, as
+// in scalarTypes; it may be nil.
+func (m *model) walk(path string, scalars map[string]bool) walkResult {
 	parts := strings.Split(path, ".")
+	fixed := make([]string, len(parts))
+	folded := false
+	column, columnField, columnAt := "", "", 0
 	cur := m
+	hasMany := 0
+	var target *model
+	relation := ""
 	for i, seg := range parts {
+		fixed[i] = seg
 		fi := lookupField(cur.structType, seg)
 		if fi == nil {
-			return walkResult{ok: false, failedAt: i, parent: cur.named}
+			if name, ok := foldField(cur.structType, seg); ok {
+				fi = lookupField(cur.structType, name)
+				fixed[i] = name
+				folded = true
+			} else if name, ok := columnNameField(cur.structType, seg); ok {
+				fi = lookupField(cur.structType, name)
+				fixed[i] = name
+				folded = true
+				if column == "" {
+					column, columnField, columnAt = seg, name, i
+				}
+			}
+		}
+		if fi == nil {
+			return walkResult{ok: false, failedAt: i, parent: cur.named, suggestions: suggestFields(cur.structType, seg), hasMany: hasMany}
 		}
-		if i == len(parts)-1 {
+		if isHasMany(fi.typ) {
+			hasMany++
+		}
+		last := i == len(parts)-1
+		if kind := unpreloadableKind(fi.typ, scalars); kind != "" && (kind != "interface" || last) {
+			return walkResult{ok: false, failedAt: i, parent: cur.named, unpreloadable: kind, fieldType: typeString(fi.typ, cur.pkg), hasMany: hasMany}
+		}
+		if last {
+			if fi.structType != nil {
+				target = nextModel(fi)
+				relation = relationType(cur, fi)
+			}
 			break
 		}
 		if fi.structType == nil {
-			return walkResult{ok: false, failedAt: i, parent: cur.named}
+			return walkResult{ok: false, failedAt: i, parent: cur.named, opaque: isInterfaceField(fi.typ), hasMany: hasMany}
 		}
 		cur = nextModel(fi)
 	}
-	return walkResult{ok: true, failedAt: -1}
+	wr := walkResult{ok: true, failedAt: -1, hasMany: hasMany, target: target, relation: relation, column: column, columnField: columnField, columnAt: columnAt}
+	if folded {
+		wr.corrected = strings.Join(fixed, ".")
+	}
+	return wr
+}
+
+// foldField returns the one field name of st that equals seg ignoring
+// case. Several such fields (ID and Id) make the match ambiguous.
+func foldField(st *types.Struct, seg string) (string, bool) {
+	match := ""
+	for _, name := range fieldNames(st) {
+		if strings.EqualFold(name, seg) {
+			if match != "" {
+				return "", false
+			}
+			match = name
+		}
+	}
+	return match, match != ""
+}
+
+// columnNameField returns the one field name of st whose default GORM
+// column name is seg, for paths written with column names ("created_by").
+func columnNameField(st *types.Struct, seg string) (string, bool) {
+	match := ""
+	for _, name := range fieldNames(st) {
+		if toDBName(name) == seg {
+			if match != "" {
+				return "", false
+			}
+			match = name
+		}
+	}
+	return match, match != ""
+}
+
+// isHasMany reports whether a relation field holds many records
+// (a slice or array, possibly named or behind pointers).
+func isHasMany(typ types.Type) bool {
+	switch derefAll(typ).Underlying().(type) {
+	case *types.Slice, *types.Array:
+		return true
+	}
+	return false
+}
+
+// relationType classifies the relation field fi of owner the way GORM
+// guesses it: "many2many" for a gorm:"many2many" tag, "has_many" for
+// another slice or array, and for a single struct "has_one" when the
+// foreign key is a field of the related struct, else "belongs_to" when it
+// is a field of owner. The foreign key is the gorm:"foreignKey" field
+// when tagged, else owner's name plus ID for has_one and the field's name
+// plus ID for belongs_to. A struct with neither is has_one, which GORM
+// would reject at run time.
+func relationType(owner *model, fi *fieldInfo) string {
+	tag := gormTag(fi.tag)
+	switch {
+	case tag["MANY2MANY"] != nil:
+		return "many2many"
+	case isHasMany(fi.typ):
+		return "has_many"
+	}
+	hasKey, belongsKey := owner.name+"ID", fi.name+"ID"
+	if fk := tag["FOREIGNKEY"]; fk != nil && *fk != "" {
+		hasKey, belongsKey = *fk, *fk
+	}
+	if lookupField(fi.structType, hasKey) == nil && lookupField(owner.structType, belongsKey) != nil {
+		return "belongs_to"
+	}
+	return "has_one"
+}
+
+// isInterfaceField reports whether a field's element type, after peeling
+// pointers, slices, and arrays, is an interface.
+func isInterfaceField(typ types.Type) bool {
+	for {
+		switch t := types.Unalias(typ).(type) {
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		case *types.Array:
+			typ = t.Elem()
+		default:
+			return types.IsInterface(typ)
+		}
+	}
+}
+
+// scalarTypes lists the types, by package path and name, that hold a
+// column value even though some of them are structs. Options.ScalarTypes
+// adds to it.
+var scalarTypes = map[string]bool{
+	"time.Time":                                 true,
+	"database/sql.Null":                         true,
+	"database/sql.NullBool":                     true,
+	"database/sql.NullByte":                     true,
+	"database/sql.NullFloat64":                  true,
+	"database/sql.NullInt16":                    true,
+	"database/sql.NullInt32":                    true,
+	"database/sql.NullInt64":                    true,
+	"database/sql.NullString":                   true,
+	"database/sql.NullTime":                     true,
+	"gorm.io/gorm.DeletedAt":                    true,
+	"gorm.io/datatypes.Date":                    true,
+	"gorm.io/datatypes.JSON":                    true,
+	"gorm.io/datatypes.JSONMap":                 true,
+	"gorm.io/datatypes.JSONSlice":               true,
+	"gorm.io/datatypes.JSONType":                true,
+	"gorm.io/datatypes.Time":                    true,
+	"gorm.io/datatypes.URL":                     true,
+	"github.com/google/uuid.UUID":               true,
+	"github.com/shopspring/decimal.Decimal":     true,
+	"github.com/shopspring/decimal.NullDecimal": true,
+}
+
+// unpreloadableKind reports which kind of type a field's element type is,
+// after peeling pointers, slices, and arrays, when it is one GORM cannot
+// preload: "scalar" (one of scalarTypes or scalars), "map", "func", "chan"
+// or "interface". It is "" otherwise.
+func unpreloadableKind(typ types.Type, scalars map[string]bool) string {
+	for {
+		switch t := types.Unalias(typ).(type) {
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		case *types.Array:
+			typ = t.Elem()
+		default:
+			if named, ok := t.(*types.Named); ok {
+				if name := qualifiedName(named); scalarTypes[name] || scalars[name] {
+					return "scalar"
+				}
+			}
+			switch typ.Underlying().(type) {
+			case *types.Map:
+				return "map"
+			case *types.Signature:
+				return "func"
+			case *types.Chan:
+				return "chan"
+			case *types.Interface:
+				return "interface"
+			}
+			return ""
+		}
+	}
+}
+
+// typeString spells typ the way it is written in pkg: types from pkg are
+// unqualified, others qualified by package name.
+func typeString(typ types.Type, pkg *types.Package) string {
+	return types.TypeString(typ, func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// qualifiedName is named's "package/path.Name", or "" for a type outside
+// any package (error).
+func qualifiedName(named *types.Named) string {
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		return ""
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
 }
 
 // nextModel builds the model for the next segment from a resolved field.
@@ -52,3 +293,15 @@ func nextModel(fi *fieldInfo) *model {
 	}
 	return next
 }
+
+// malformedSegment returns the index of the first segment of a dotted path
+// that is not an exported Go identifier (empty segments from "User..Profile",
+// ".User" or "User." included), or -1 when the path is well-formed.
+func malformedSegment(path string) int {
+	for i, seg := range strings.Split(path, ".") {
+		if !token.IsIdentifier(seg) || !token.IsExported(seg) {
+			return i
+		}
+	}
+	return -1
+}
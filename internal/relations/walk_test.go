@@ -1,6 +1,9 @@
 package relations
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 const nestedFixture = `package main
 
@@ -48,7 +51,7 @@ func modelFromFixture(t *testing.T, fixture string) *model {
 
 func TestWalk_SingleSegment_OK(t *testing.T) {
 	m := modelFromFixture(t, nestedFixture)
-	got := m.walk("User")
+	got := m.walk("User", nil)
 	if !got.ok {
 		t.Fatalf("expected ok=true, got %+v", got)
 	}
@@ -59,7 +62,7 @@ func TestWalk_SingleSegment_OK(t *testing.T) {
 
 func TestWalk_DeepPath_OK(t *testing.T) {
 	m := modelFromFixture(t, nestedFixture)
-	got := m.walk("User.Profile.Address")
+	got := m.walk("User.Profile.Address", nil)
 	if !got.ok {
 		t.Fatalf("expected ok=true on User.Profile.Address, got %+v", got)
 	}
@@ -67,7 +70,7 @@ func TestWalk_DeepPath_OK(t *testing.T) {
 
 func TestWalk_FailsAtFirstSegment_ReportsIndex0(t *testing.T) {
 	m := modelFromFixture(t, nestedFixture)
-	got := m.walk("Customer")
+	got := m.walk("Customer", nil)
 	if got.ok {
 		t.Fatalf("expected ok=false for missing first segment")
 	}
@@ -81,7 +84,7 @@ func TestWalk_FailsAtFirstSegment_ReportsIndex0(t *testing.T) {
 
 func TestWalk_FailsAtMiddleSegment_ReportsCorrectIndexAndParent(t *testing.T) {
 	m := modelFromFixture(t, nestedFixture)
-	got := m.walk("User.Profil.Address")
+	got := m.walk("User.Profil.Address", nil)
 	if got.ok {
 		t.Fatal("expected ok=false on typo'd middle segment")
 	}
@@ -97,7 +100,7 @@ func TestWalk_FailsWhenSegmentIsScalar(t *testing.T) {
 	// Bio is a string, can't recurse into it. "User.Profile.Bio.Anything"
 	// must fail at index 2 because Bio resolves but has no struct type.
 	m := modelFromFixture(t, nestedFixture)
-	got := m.walk("User.Profile.Bio.Anything")
+	got := m.walk("User.Profile.Bio.Anything", nil)
 	if got.ok {
 		t.Fatal("expected ok=false when descending into a scalar field")
 	}
@@ -130,8 +133,115 @@ func GetOrders(db *gorm.DB) {
 	db.Preload("Creator").Find(&orders)
 }
 `)
-	got := m.walk("Creator")
+	got := m.walk("Creator", nil)
 	if !got.ok {
 		t.Fatalf("expected promoted field 'Creator' to resolve, got %+v", got)
 	}
 }
+
+func TestWalk_SelfReferential(t *testing.T) {
+	m := modelFromFixture(t, `package main
+
+import "gorm.io/gorm"
+
+type Category struct {
+	ID       int64
+	ParentID *int64
+	Parent   *Category
+	Children []Category
+}
+
+// Node embeds itself, so promoted-field lookup has a cycle to follow.
+type Node struct {
+	*Node
+	Category Category
+}
+
+func GetNodes(db *gorm.DB) {
+	var nodes []Node
+	db.Preload("Category").Find(&nodes)
+}
+`)
+	tests := []struct {
+		path     string
+		ok       bool
+		failedAt int
+		suggest  []string
+	}{
+		{path: "Category.Parent.Parent.Parent", ok: true, failedAt: -1},
+		{path: "Category.Children.Parent.Children", ok: true, failedAt: -1},
+		{path: "Node.Node.Category.Parent", ok: true, failedAt: -1},
+		{path: "Category.Parnt", failedAt: 1, suggest: []string{"Parent"}},
+		{path: "Missing", failedAt: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := m.walk(tt.path, nil)
+			if got.ok != tt.ok || got.failedAt != tt.failedAt {
+				t.Fatalf("walk(%q) = ok %v, failedAt %d; want ok %v, failedAt %d", tt.path, got.ok, got.failedAt, tt.ok, tt.failedAt)
+			}
+			if tt.suggest != nil && !reflect.DeepEqual(got.suggestions, tt.suggest) {
+				t.Errorf("suggestions = %v, want %v", got.suggestions, tt.suggest)
+			}
+		})
+	}
+}
+
+func TestMalformedSegment(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"User", -1},
+		{"User.Profile.Address", -1},
+		{"User..Profile", 1},
+		{".User", 0},
+		{"User.", 1},
+		{"...", 0},
+		{"User.Pro file", 1},
+		{"user", 0},
+	}
+	for _, tt := range tests {
+		if got := malformedSegment(tt.path); got != tt.want {
+			t.Errorf("malformedSegment(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWalk_InterfaceSegment_Opaque(t *testing.T) {
+	m := modelFromFixture(t, `package main
+
+import "gorm.io/gorm"
+
+type Owner interface {
+	OwnerName() string
+}
+
+type Profile struct {
+	Bio string
+}
+
+type Pet struct {
+	Owner  Owner
+	Owners []Owner
+	Vet    Profile
+}
+
+func GetPets(db *gorm.DB) {
+	var pets []Pet
+	db.Preload("Owner").Find(&pets)
+}
+`)
+	for _, path := range []string{"Owner.Profile", "Owners.Profile"} {
+		got := m.walk(path, nil)
+		if got.ok || !got.opaque {
+			t.Errorf("%s: expected an opaque stop, got %+v", path, got)
+		}
+		if got.failedAt != 0 {
+			t.Errorf("%s: expected failedAt=0, got %d", path, got.failedAt)
+		}
+	}
+	if got := m.walk("Vet.Bio.Anything", nil); got.opaque {
+		t.Errorf("scalar segment must not be opaque, got %+v", got)
+	}
+}
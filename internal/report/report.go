@@ -0,0 +1,230 @@
+// Package report turns verification results into the counted, filtered
+// AnalysisResult shared by the output writers and the Go API.
+package report
+
+import (
+	"cmp"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+// Filter narrows results the way the -V and -e flags do. errorsOnly keeps
+// errors; validationOnly keeps results that were actually verified (valid,
+// error, warning). With neither set, results is returned as is.
+func Filter(results []models.PreloadResult, validationOnly, errorsOnly bool) []models.PreloadResult {
+	if !validationOnly && !errorsOnly {
+		return results
+	}
+	var out []models.PreloadResult
+	for _, r := range results {
+		if errorsOnly && r.Status == "error" {
+			out = append(out, r)
+		} else if validationOnly && (r.Status == "valid" || r.Status == "error" || r.Status == "warning") {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Select keeps the results whose relation matches one of relationPats and
+// whose model matches one of modelPats, the way --only-relation and
+// --only-model narrow a report; an empty list matches everything. Patterns
+// are path.Match patterns: a relation pattern is matched against the whole
+// path ("Items*" matches "Items.Product"), a model pattern against the
+// model's name ("Invoice"), or its qualified name ("db.Invoice") when it
+// holds a dot.
+func Select(results []models.PreloadResult, relationPats, modelPats []string) []models.PreloadResult {
+	if len(relationPats) == 0 && len(modelPats) == 0 {
+		return results
+	}
+	var out []models.PreloadResult
+	for _, r := range results {
+		if matchAny(relationPats, r.Relation, r.Relation) && matchAny(modelPats, r.Model, modelName(r.Model)) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// matchAny reports whether name, or short for a pattern without a dot,
+// matches one of patterns, or there are none.
+func matchAny(patterns []string, name, short string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pat := range patterns {
+		target := short
+		if strings.Contains(pat, ".") {
+			target = name
+		}
+		if ok, _ := path.Match(pat, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// modelName is a displayed model ("db.Invoice") without its package.
+func modelName(model string) string {
+	if _, name, ok := strings.Cut(model, "."); ok {
+		return name
+	}
+	return model
+}
+
+// Compare orders results by file, line, column and relation, the order
+// Build and the file writers list them in.
+func Compare(a, b models.PreloadResult) int {
+	return cmp.Or(
+		strings.Compare(a.File, b.File),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.Column, b.Column),
+		strings.Compare(a.Relation, b.Relation),
+	)
+}
+
+// Build summarizes every result of a run and keeps for display only those
+// Filter keeps, sorted by Compare. The counts and accuracy are the same
+// whatever the filters; Displayed tells how many results they kept. All
+// output writers and the Go API summarize through here.
+func Build(results []models.PreloadResult, validationOnly, errorsOnly bool) *models.AnalysisResult {
+	res := Summarize(results)
+	res.Results = slices.Clone(Filter(results, validationOnly, errorsOnly))
+	slices.SortStableFunc(res.Results, Compare)
+	res.Displayed = len(res.Results)
+	return res
+}
+
+// Summarize counts results by status and computes their accuracy and
+// coverage.
+func Summarize(results []models.PreloadResult) *models.AnalysisResult {
+	res := &models.AnalysisResult{Total: len(results), Displayed: len(results), Results: results}
+	for _, r := range results {
+		switch r.Status {
+		case "valid":
+			res.Valid++
+		case "error":
+			res.Errors++
+		case "warning":
+			res.Warnings++
+		case "info":
+			res.Info++
+		case "dynamic":
+			res.Dynamic++
+		case "unknown":
+			res.Unknown++
+		case "skipped":
+			res.Skipped++
+		case "suppressed":
+			res.Suppressed++
+		}
+	}
+	res.Accuracy = Accuracy(res)
+	res.Coverage = Coverage(res)
+	return res
+}
+
+// verified counts the verified preloads of res: the valid and error ones,
+// whose relation path was checked against a model. Dynamic, unknown, and
+// advisory results are not. Accuracy, Coverage and Stats all count this.
+func verified(res *models.AnalysisResult) int {
+	return res.Valid + res.Errors
+}
+
+// Accuracy is the share of verified preloads that are not errors:
+// valid / (valid + errors). With nothing verified it is 1.
+func Accuracy(res *models.AnalysisResult) float64 {
+	if verified(res) == 0 {
+		return 1
+	}
+	return float64(res.Valid) / float64(verified(res))
+}
+
+// Coverage is the share of preloads that Accuracy covers:
+// (valid + errors) / total. A run with many dynamic or unknown results has
+// an accuracy that says little, and a low coverage shows it. With no
+// preloads it is 1.
+func Coverage(res *models.AnalysisResult) float64 {
+	if res.Total == 0 {
+		return 1
+	}
+	return float64(verified(res)) / float64(res.Total)
+}
+
+// Stats computes the coverage statistics of results, listing at most top
+// models by preload count. Verified counts as in Coverage.
+func Stats(results []models.PreloadResult, top int) *models.Stats {
+	st := &models.Stats{Preloads: len(results), Sources: map[string]int{}}
+	files := map[string]bool{}
+	perModel := map[string]int{}
+	for _, r := range results {
+		files[r.File] = true
+		if r.Source != "" {
+			st.Sources[r.Source]++
+		}
+		if r.Model != "" && r.Model != "Unknown" {
+			perModel[r.Model]++
+		}
+		if r.Kind == "unresolved-model" {
+			st.Unresolved++
+		}
+	}
+	st.Files = len(files)
+	st.Models = len(perModel)
+	st.Verified = verified(Summarize(results))
+	st.TopModels = []models.ModelCount{}
+	for m, n := range perModel {
+		st.TopModels = append(st.TopModels, models.ModelCount{Model: m, Preloads: n})
+	}
+	sort.Slice(st.TopModels, func(i, j int) bool {
+		a, b := st.TopModels[i], st.TopModels[j]
+		if a.Preloads != b.Preloads {
+			return a.Preloads > b.Preloads
+		}
+		return a.Model < b.Model
+	})
+	if len(st.TopModels) > top {
+		st.TopModels = st.TopModels[:top]
+	}
+	return st
+}
+
+// DirSummary is the summary of the results under one top-level directory.
+type DirSummary struct {
+	Dir    string
+	Result *models.AnalysisResult
+}
+
+// ByDirectory summarizes results per top-level directory relative to root,
+// sorted by directory. Files directly in root are grouped under "."; files
+// outside root under their own directory.
+func ByDirectory(results []models.PreloadResult, root string) []DirSummary {
+	grouped := map[string][]models.PreloadResult{}
+	for _, r := range results {
+		dir := topLevelDir(r.File, root)
+		grouped[dir] = append(grouped[dir], r)
+	}
+	out := make([]DirSummary, 0, len(grouped))
+	for dir, rs := range grouped {
+		out = append(out, DirSummary{Dir: dir, Result: Summarize(rs)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Dir < out[j].Dir })
+	return out
+}
+
+func topLevelDir(file, root string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(filepath.Dir(file))
+	}
+	first, _, found := strings.Cut(filepath.ToSlash(rel), "/")
+	if !found {
+		return "."
+	}
+	return first
+}
@@ -0,0 +1,232 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/models"
+)
+
+func TestFilter(t *testing.T) {
+	results := []models.PreloadResult{
+		{Status: "valid"},
+		{Status: "error"},
+		{Status: "dynamic"},
+		{Status: "unknown"},
+	}
+
+	errOnly := Filter(results, false, true)
+	if len(errOnly) != 1 || errOnly[0].Status != "error" {
+		t.Errorf("errors-only: expected 1 error, got %d", len(errOnly))
+	}
+
+	validOnly := Filter(results, true, false)
+	if len(validOnly) != 2 {
+		t.Errorf("validation-only: expected 2 (valid+error), got %d", len(validOnly))
+	}
+
+	all := Filter(results, false, false)
+	if len(all) != 4 {
+		t.Errorf("unfiltered: expected 4, got %d", len(all))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	results := []models.PreloadResult{
+		{Relation: "Items", Model: "db.Invoice"},
+		{Relation: "Items.Product", Model: "db.Invoice"},
+		{Relation: "Machine", Model: "db.Invoice"},
+		{Relation: "Items", Model: "billing.Invoice"},
+		{Relation: "Items", Model: "db.Order"},
+	}
+	tests := []struct {
+		name              string
+		relations, models []string
+		want              []int
+	}{
+		{"none", nil, nil, []int{0, 1, 2, 3, 4}},
+		{"relation", []string{"Items*"}, nil, []int{0, 1, 3, 4}},
+		{"nested relation", []string{"Items.Product"}, nil, []int{1}},
+		{"model name", nil, []string{"Invoice"}, []int{0, 1, 2, 3}},
+		{"qualified model", nil, []string{"db.*"}, []int{0, 1, 2, 4}},
+		{"both", []string{"Items"}, []string{"Invoice"}, []int{0, 3}},
+		{"several patterns", []string{"Machine", "Items.*"}, []string{"Invoice", "Order"}, []int{1, 2}},
+		{"case sensitive", []string{"items*"}, nil, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, r := range Select(results, tt.relations, tt.models) {
+			got = append(got, slices.IndexFunc(results, func(x models.PreloadResult) bool {
+				return x.Relation == r.Relation && x.Model == r.Model
+			}))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	res := Summarize([]models.PreloadResult{
+		{Status: "valid"},
+		{Status: "valid"},
+		{Status: "error"},
+		{Status: "warning"},
+		{Status: "info"},
+		{Status: "dynamic"},
+		{Status: "unknown"},
+		{Status: "skipped"},
+	})
+	got := [...]int{res.Total, res.Valid, res.Errors, res.Warnings, res.Info, res.Dynamic, res.Unknown, res.Skipped}
+	want := [...]int{8, 2, 1, 1, 1, 1, 1, 1}
+	if got != want {
+		t.Errorf("expected counts %v, got %v", want, got)
+	}
+}
+
+func TestBuild_FilterInvariant(t *testing.T) {
+	results := []models.PreloadResult{
+		{Status: "valid"},
+		{Status: "valid"},
+		{Status: "valid"},
+		{Status: "error"},
+		{Status: "dynamic"},
+		{Status: "unknown"},
+	}
+
+	tests := []struct {
+		name                       string
+		validationOnly, errorsOnly bool
+		displayed                  int
+	}{
+		{"unfiltered", false, false, 6},
+		{"validation only", true, false, 4},
+		{"errors only", false, true, 1},
+		{"both", true, true, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Build(results, tt.validationOnly, tt.errorsOnly)
+			got := [...]int{res.Total, res.Valid, res.Errors, res.Dynamic, res.Unknown}
+			want := [...]int{6, 3, 1, 1, 1}
+			if got != want {
+				t.Errorf("expected counts %v, got %v", want, got)
+			}
+			if res.Accuracy != 0.75 {
+				t.Errorf("expected accuracy 0.75, got %v", res.Accuracy)
+			}
+			if res.Displayed != tt.displayed || len(res.Results) != tt.displayed {
+				t.Errorf("expected %d displayed, got %d (%d results)", tt.displayed, res.Displayed, len(res.Results))
+			}
+		})
+	}
+}
+
+func TestBuild_Sorted(t *testing.T) {
+	results := []models.PreloadResult{
+		{File: "b.go", Line: 3, Column: 5, Relation: "User"},
+		{File: "a.go", Line: 9, Column: 13, Relation: "Items"},
+		{File: "a.go", Line: 9, Column: 13, Relation: "Customer"},
+		{File: "a.go", Line: 9, Column: 2, Relation: "User"},
+		{File: "a.go", Line: 4, Column: 40, Relation: "Zone"},
+	}
+	res := Build(results, false, false)
+	var got []string
+	for _, r := range res.Results {
+		got = append(got, fmt.Sprintf("%s:%d:%d:%s", r.File, r.Line, r.Column, r.Relation))
+	}
+	want := []string{"a.go:4:40:Zone", "a.go:9:2:User", "a.go:9:13:Customer", "a.go:9:13:Items", "b.go:3:5:User"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if results[0].File != "b.go" {
+		t.Error("expected Build to leave its input in place")
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	tests := []struct {
+		res  models.AnalysisResult
+		want float64
+	}{
+		{models.AnalysisResult{Valid: 3, Errors: 1}, 0.75},
+		{models.AnalysisResult{Valid: 2, Dynamic: 5, Unknown: 1}, 1},
+		{models.AnalysisResult{}, 1},
+		{models.AnalysisResult{Errors: 2}, 0},
+	}
+	for _, tt := range tests {
+		if got := Accuracy(&tt.res); got != tt.want {
+			t.Errorf("Accuracy(%+v) = %v, want %v", tt.res, got, tt.want)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		res  models.AnalysisResult
+		want float64
+	}{
+		{models.AnalysisResult{Total: 4, Valid: 3, Errors: 1}, 1},
+		{models.AnalysisResult{Total: 8, Valid: 2, Dynamic: 5, Unknown: 1}, 0.25},
+		{models.AnalysisResult{}, 1},
+		{models.AnalysisResult{Total: 2, Warnings: 2}, 0},
+	}
+	for _, tt := range tests {
+		if got := Coverage(&tt.res); got != tt.want {
+			t.Errorf("Coverage(%+v) = %v, want %v", tt.res, got, tt.want)
+		}
+	}
+}
+
+func TestByDirectory(t *testing.T) {
+	results := []models.PreloadResult{
+		{File: "/src/app/internal/repo/order.go", Status: "valid"},
+		{File: "/src/app/internal/api/user.go", Status: "error"},
+		{File: "/src/app/cmd/main.go", Status: "valid"},
+		{File: "/src/app/main.go", Status: "unknown"},
+		{File: "/elsewhere/x.go", Status: "valid"},
+	}
+	got := ByDirectory(results, "/src/app")
+
+	want := []struct {
+		dir          string
+		total, valid int
+	}{
+		{".", 1, 0},
+		{"/elsewhere", 1, 1},
+		{"cmd", 1, 1},
+		{"internal", 2, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d directories, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Dir != w.dir || got[i].Result.Total != w.total || got[i].Result.Valid != w.valid {
+			t.Errorf("dir %d: expected %s total=%d valid=%d, got %s total=%d valid=%d",
+				i, w.dir, w.total, w.valid, got[i].Dir, got[i].Result.Total, got[i].Result.Valid)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	st := Stats([]models.PreloadResult{
+		{File: "a.go", Model: "db.User", Source: "chain", Status: "valid"},
+		{File: "a.go", Model: "db.User", Source: "variable", Status: "error"},
+		{File: "b.go", Model: "db.Order", Source: "chain", Status: "warning", Kind: "has-many-depth"},
+		{File: "b.go", Model: "db.Order", Source: "chain", Status: "warning", Kind: "dynamic"},
+		{File: "c.go", Model: "db.Item", Source: "field", Status: "skipped"},
+		{File: "c.go", Model: "Unknown", Source: "chain", Status: "unknown", Kind: "unresolved-model"},
+	}, 2)
+
+	if st.Files != 3 || st.Models != 3 || st.Preloads != 6 || st.Verified != 2 || st.Unresolved != 1 {
+		t.Errorf("unexpected counts: %+v", st)
+	}
+	if st.Sources["chain"] != 4 || st.Sources["variable"] != 1 || st.Sources["field"] != 1 {
+		t.Errorf("unexpected sources: %v", st.Sources)
+	}
+	want := []models.ModelCount{{Model: "db.Order", Preloads: 2}, {Model: "db.User", Preloads: 2}}
+	if len(st.TopModels) != len(want) || st.TopModels[0] != want[0] || st.TopModels[1] != want[1] {
+		t.Errorf("top models = %v, want %v", st.TopModels, want)
+	}
+}
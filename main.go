@@ -1,36 +1,162 @@
 package main
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	runtimedebug "runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/your-moon/gpc/internal/engine"
+	"github.com/spf13/pflag"
+	"github.com/your-moon/gpc/internal/config"
+	"github.com/your-moon/gpc/internal/gitdiff"
 	"github.com/your-moon/gpc/internal/models"
 	"github.com/your-moon/gpc/internal/output"
+	"github.com/your-moon/gpc/internal/report"
+	"github.com/your-moon/gpc/pkg/gpc"
 )
 
 var (
 	outputFormat   string
 	outputFile     string
+	formatTmpl     string
+	summaryTmpl    string
+	metricsFile    string
+	mkdir          bool
 	validationOnly bool
 	errorsOnly     bool
+	summaryOnly    bool
+	includeTests   bool
+	warnHasMany    bool
+	maxHasMany     int
+	warnDynamic    bool
+	warnRedundant  bool
+	warnDuplicate  bool
+	failOn         string
+	maxErrors      int
+	strict         bool
+	preloadFields  []string
+	allowModels    []string
+	ignoreNolint   bool
+	colorMode      string
+	noColor        bool
+	configPath     string
+	finishers      []string
+	ignoreModels   []string
+	ignoreRels     []string
+	scalarTypes    []string
+	checkSelects   bool
+	severity       map[string]string
+	excludes       []string
+	onlyFiles      []string
+	onlyRelations  []string
+	onlyModels     []string
+	showScope      bool
+	funcs          []string
+	debug          bool
+	verbose        bool
+	explain        bool
+	contextLines   int
+	stats          bool
+	logFile        string
+	noProgress     bool
+	includeVendor  bool
+	diffRef        string
+	diffLines      bool
+	lineSpec       string
+	listFiles      bool
 )
 
+// stdin is where a "-" target reads its file list from.
+var stdin io.Reader = os.Stdin
+
+// strictPreset lists the flag values --strict stands for. Each is applied
+// only when its flag was not given explicitly, so individual settings can
+// still be overridden on top of the preset. Scalar fields and malformed
+// paths are pinned as errors, so a --severity that downgrades other kinds
+// leaves them failing. Models are never inferred from names, receivers
+// are always checked to be *gorm.DB and nested paths are always verified
+// segment by segment, so those need no flag.
+var strictPreset = map[string]string{
+	"warn-dynamic": "true",
+	"fail-on":      "unknown",
+	"severity":     "not-preloadable=error,malformed-path=error",
+}
+
 var rootCmd = &cobra.Command{
-	Use:   "gpc [directory or file]",
+	Use:   "gpc [directory, file, package pattern, or -]...",
 	Short: "Static analysis tool for GORM Preload() calls",
 	Long:  "Validates relation names in GORM Preload() calls using type-checked analysis.",
-	Args:  cobra.ExactArgs(1),
-	Run:   run,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("diff") {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	Run: run,
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&outputFormat, "format", "o", "text", "Output format: text or json")
-	rootCmd.Flags().StringVarP(&outputFile, "file", "f", "", "Write JSON output to file (implies -o json)")
-	rootCmd.Flags().BoolVarP(&validationOnly, "valid", "V", false, "Show only validated results (valid and errors)")
+	rootCmd.Flags().StringVarP(&outputFormat, "format", "o", "text", "Output format: text, plain, json, ndjson, junit, gitlab, rdjson, rdjsonl, tap, or github (default github under GitHub Actions)")
+	rootCmd.Flags().StringVarP(&outputFile, "file", "f", "", "Write plain, json, ndjson, junit, gitlab, rdjson or tap output to file, or to stdout with - (implies -o json unless -o is given; with -o github, also writes json)")
+	rootCmd.Flags().StringVar(&metricsFile, "metrics-file", "", "Write per-directory Prometheus gauges to file (textfile collector format)")
+	rootCmd.Flags().StringVar(&formatTmpl, "format-template", "", "Write each result with this Go text/template instead of -o, or a preset: emacs, vi, short")
+	rootCmd.Flags().StringVar(&summaryTmpl, "summary-template", "", "With --format-template, end with this Go text/template of the counts")
+	rootCmd.Flags().BoolVar(&mkdir, "mkdir", false, "Create missing parent directories of -f and --metrics-file paths")
+	rootCmd.Flags().BoolVarP(&validationOnly, "valid", "V", false, "Show only validated results (valid and errors); also --validation-only")
 	rootCmd.Flags().BoolVarP(&errorsOnly, "errors-only", "e", false, "Show only errors")
+	rootCmd.Flags().BoolVar(&summaryOnly, "summary-only", false, "Print only the counts: no per-preload lines, and an empty results array in JSON")
+	rootCmd.Flags().BoolVar(&includeTests, "tests", false, "Also analyze _test.go files")
+	rootCmd.Flags().BoolVar(&includeVendor, "include-vendor", false, "Also analyze vendored packages the analyzed packages import")
+	rootCmd.Flags().BoolVar(&warnHasMany, "warn-has-many", false, "Warn on relation paths crossing too many has-many relations")
+	rootCmd.Flags().IntVar(&maxHasMany, "max-has-many", 3, "Has-many relations a path may cross before --warn-has-many reports it")
+	rootCmd.Flags().BoolVar(&warnDynamic, "warn-dynamic", false, "Report dynamic (non-constant) relation arguments as warnings")
+	rootCmd.Flags().BoolVar(&warnRedundant, "warn-redundant", false, "Report preloads already loaded by a nested preload in the same chain (informational)")
+	rootCmd.Flags().BoolVar(&warnDuplicate, "warn-duplicate", true, "Report a relation preloaded twice in the same chain as a warning; --warn-duplicate=false turns it off")
+	rootCmd.Flags().StringSliceVar(&preloadFields, "preload-fields", []string{"Preloads"}, "Struct field name patterns whose []string literals are checked as relation names")
+	rootCmd.Flags().StringSliceVar(&allowModels, "models", nil, "Verify only these models (glob patterns, e.g. Invoice,Trip*); others are reported as skipped")
+	rootCmd.Flags().StringArrayVar(&onlyFiles, "only-files", nil, "Report only findings in files matching this glob, like --exclude (repeatable); counts cover only them")
+	rootCmd.Flags().StringSliceVar(&onlyRelations, "only-relation", nil, "Report only findings on relation paths matching these glob patterns (e.g. Items*); counts cover only them")
+	rootCmd.Flags().StringSliceVar(&onlyModels, "only-model", nil, "Report only findings on models matching these glob patterns (e.g. Invoice); counts cover only them")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip preloads in files matching this glob (repeatable; ** matches directories, e.g. **/mocks/**)")
+	rootCmd.Flags().StringSliceVar(&funcs, "func", nil, "Check only preloads in these functions (repeatable; globs; methods as Type.Method or Method)")
+	rootCmd.Flags().StringSliceVar(&finishers, "finishers", nil, "Extra finisher methods that run a query, like Find and First (e.g. FindInBatches)")
+	rootCmd.Flags().StringSliceVar(&ignoreModels, "ignore-models", nil, "Report findings on these models (glob patterns) as suppressed")
+	rootCmd.Flags().StringSliceVar(&ignoreRels, "ignore-relations", nil, "Report findings on these relation paths (glob patterns) as suppressed")
+	rootCmd.Flags().StringSliceVar(&scalarTypes, "scalar-types", nil, "Extra types (package/path.Name) that hold a column value, not a relation, like the built-in time.Time")
+	rootCmd.Flags().BoolVar(&checkSelects, "check-select-columns", false, "Check the columns a Preload scope selects (db.Select(\"id, name\")) against the relation's struct")
+	rootCmd.Flags().StringToStringVar(&severity, "severity", nil, "Override the status of findings by kind, e.g. has-many-depth=error (error, warning, or info)")
+	rootCmd.Flags().BoolVar(&ignoreNolint, "ignore-bare-nolint", false, "Don't let a //nolint without linter names suppress findings (//nolint:gpc still does)")
+	rootCmd.Flags().StringVar(&colorMode, "color", "auto", "Colorize console output: auto (terminal without NO_COLOR), always, or never")
+	rootCmd.Flags().BoolVar(&noColor, "no-color", false, "Don't colorize console output (same as --color=never)")
+	rootCmd.Flags().StringVar(&failOn, "fail-on", "error", "Exit non-zero on: error, unknown (errors and unverifiable preloads), or never")
+	rootCmd.Flags().BoolVar(&debug, "debug", false, "Print timestamped diagnostics to stderr: per-pass counts and timings, files skipped per --exclude pattern")
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Print a timestamped line per verified preload to stderr")
+	rootCmd.Flags().BoolVar(&explain, "explain", false, "Follow each result with how its model was resolved: the query call, how the preload reaches it, and the type")
+	rootCmd.Flags().IntVar(&contextLines, "context", 0, "Print this many source lines before and after each error in console output")
+	rootCmd.Flags().BoolVar(&showScope, "show-scope", false, "Follow each finding in console output with its function and preload call")
+	rootCmd.Flags().BoolVar(&stats, "stats", false, "Add coverage statistics (files, models, verified preloads, sources, top models) to console and JSON output")
+	rootCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Don't show the progress line on a terminal's stderr")
+	rootCmd.Flags().StringVar(&logFile, "log-file", "", "Write --debug and --verbose output to this file instead of stderr")
+	rootCmd.Flags().StringVar(&diffRef, "diff", "", "Check only the Go files changed in <ref>...HEAD (replaces targets)")
+	rootCmd.Flags().BoolVar(&diffLines, "diff-lines", false, "With --diff, report only preloads on changed lines")
+	rootCmd.Flags().StringVar(&lineSpec, "lines", "", "With a single Go file target, report only preloads on lines start:end")
+	rootCmd.Flags().BoolVar(&listFiles, "list-files", false, "Print the Go files that would be checked, one per line, and exit without analyzing")
+	rootCmd.Flags().StringVar(&configPath, "config", "", "Read settings from this file instead of the nearest "+config.FileName)
+	rootCmd.Flags().IntVar(&maxErrors, "max-errors", 0, "Pass while the --fail-on failures number at most this many (to ratchet down gradually)")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "Preset for maximum safety: --warn-dynamic --fail-on=unknown --severity not-preloadable=error,malformed-path=error (explicit flags override)")
+	rootCmd.Flags().SetNormalizeFunc(flagAliases)
 }
 
 func main() {
@@ -40,58 +166,593 @@ func main() {
 }
 
 func run(cmd *cobra.Command, args []string) {
-	target := args[0]
+	if code := execute(cmd.Flags(), args...); code != 0 {
+		os.Exit(code)
+	}
+}
 
-	info, err := os.Stat(target)
+// flagAliases maps alternative flag names to the flags they stand for.
+func flagAliases(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "validation-only" {
+		name = "valid"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// execute runs the analysis for targets and returns the exit code: 0 on
+// success, 1 on a tool error, 2 when the results fail the --fail-on policy
+// more than --max-errors times.
+func execute(flags *pflag.FlagSet, targets ...string) int {
+	if err := loadConfig(flags, targets); err != nil {
+		fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+		return 1
+	}
+	if strict {
+		applyPreset(flags, strictPreset)
+	}
+	if failOn != "error" && failOn != "unknown" && failOn != "never" {
+		fmt.Fprintf(os.Stderr, "gpc: invalid --fail-on %q (want error, unknown, or never)\n", failOn)
+		return 1
+	}
+	if outputFile == output.Stdout && flags.Changed("format") && outputFormat == "github" {
+		fmt.Fprintln(os.Stderr, "gpc: -f - can't be combined with -o github, which writes to stdout")
+		return 1
+	}
+	if maxErrors < 0 {
+		fmt.Fprintf(os.Stderr, "gpc: invalid --max-errors %d (want 0 or more)\n", maxErrors)
+		return 1
+	}
+	if contextLines < 0 {
+		fmt.Fprintf(os.Stderr, "gpc: invalid --context %d (want 0 or more)\n", contextLines)
+		return 1
+	}
+	if maxHasMany < 1 {
+		fmt.Fprintf(os.Stderr, "gpc: invalid --max-has-many %d (want 1 or more)\n", maxHasMany)
+		return 1
+	}
+	if noColor {
+		colorMode = "never"
+	}
+	color, err := output.UseColor(colorMode, os.Stdout)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	// Templates are checked before the analysis so a typo fails fast
+	var templates *output.Templates
+	switch {
+	case formatTmpl != "" && flags.Changed("format"):
+		fmt.Fprintln(os.Stderr, "gpc: --format-template can't be combined with -o")
+		return 1
+	case formatTmpl != "":
+		if templates, err = output.ParseTemplates(formatTmpl, summaryTmpl); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case summaryTmpl != "":
+		fmt.Fprintln(os.Stderr, "gpc: --summary-template requires --format-template")
+		return 1
 	}
 
-	var dir, filterFile string
-	if info.IsDir() {
-		dir = target
-	} else {
-		dir = filepath.Dir(target)
-		filterFile, _ = filepath.Abs(target)
+	opts := gpc.Options{
+		IncludeTests:       includeTests,
+		IncludeVendor:      includeVendor,
+		WarnDynamic:        warnDynamic,
+		WarnRedundant:      warnRedundant,
+		AllowDuplicates:    !warnDuplicate,
+		PreloadFields:      preloadFields,
+		Models:             allowModels,
+		IgnoreBareNolint:   ignoreNolint,
+		Finishers:          finishers,
+		IgnoreModels:       ignoreModels,
+		IgnoreRelations:    ignoreRels,
+		Severity:           severity,
+		Exclude:            excludes,
+		OnlyFiles:          onlyFiles,
+		OnlyRelations:      onlyRelations,
+		OnlyModels:         onlyModels,
+		Funcs:              funcs,
+		ScalarTypes:        scalarTypes,
+		CheckSelectColumns: checkSelects,
+		Explain:            explain,
+	}
+	var log io.Writer = os.Stderr
+	if logFile != "" && (debug || verbose) {
+		f, err := os.Create(logFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		log = f
+	}
+	if debug {
+		opts.Debug = log
+		fmt.Fprintf(log, "%s gpc: debug: options: %s\n", time.Now().Format("15:04:05.000"), optionsLine(flags))
+	}
+	if verbose {
+		opts.Verbose = log
+	}
+	if warnHasMany {
+		opts.MaxHasManyHops = maxHasMany
 	}
 
-	absDir, err := filepath.Abs(dir)
+	var diff *gitdiff.Diff
+	if diffRef != "" {
+		if len(targets) > 0 {
+			fmt.Fprintln(os.Stderr, "gpc: --diff takes no targets")
+			return 1
+		}
+		cwd, err := os.Getwd()
+		if err == nil {
+			diff, err = gitdiff.Changed(cwd, diffRef)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: --diff: %v\n", err)
+			return 1
+		}
+		targets = diff.GoFiles()
+	} else if diffLines {
+		fmt.Fprintln(os.Stderr, "gpc: --diff-lines requires --diff")
+		return 1
+	}
+	targets, err = stdinTargets(targets, stdin, os.Stderr)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
-
-	results, err := engine.Analyze(absDir)
+	if listFiles {
+		files, err := gpc.ListFiles(targets, opts)
+		if err == nil {
+			err = output.WriteFileList(os.Stdout, files)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	var lines *lineRange
+	if lineSpec != "" {
+		if len(targets) != 1 || filepath.Ext(targets[0]) != ".go" {
+			fmt.Fprintln(os.Stderr, "gpc: --lines requires a single Go file target")
+			return 1
+		}
+		if lines, err = parseLineRange(lineSpec, targets[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	}
+	// narrow applies --diff-lines and --lines
+	narrow := func(results []models.PreloadResult) []models.PreloadResult {
+		if diffLines {
+			results = changedLines(results, diff)
+		}
+		if lines != nil {
+			results = lines.filter(results)
+		}
+		return results
+	}
+	// ndjson streams each file's results as soon as it is verified
+	var stream *output.NDJSONWriter
+	if outputFormat == "ndjson" {
+		var w io.Writer = os.Stdout
+		if outputFile != "" && outputFile != output.Stdout {
+			var f *os.File
+			if err := writeOutput(outputFile, func(path string) (err error) {
+				f, err = os.Create(path)
+				return err
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+		stream = output.NewNDJSONWriter(w)
+		opts.OnFile = func(_ string, results []gpc.PreloadResult) {
+			if summaryOnly {
+				return
+			}
+			stream.WriteResults(report.Filter(narrow(results), validationOnly, errorsOnly))
+		}
+	}
+	// Diagnostics on stderr would tear the progress line
+	var progress *output.Progress
+	if !noProgress && (logFile != "" || !debug && !verbose) {
+		if progress = output.NewProgress(os.Stderr); progress != nil {
+			opts.Progress = progress.Update
+		}
+	}
+	res, err := gpc.AnalyzeTargets(targets, opts)
+	progress.Done()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	// --lines, --diff-lines and the --only-* filters decide which results
+	// the summary and --fail-on count; --validation-only and --errors-only
+	// only choose the ones listed
+	results := narrow(res.Results)
+	shown := report.Build(results, validationOnly, errorsOnly)
+	if summaryOnly {
+		shown.Results = []models.PreloadResult{}
+		shown.Displayed = 0
+	}
+	shown.SkippedTestFiles = res.SkippedTestFiles
+	shown.OnlyFiles = res.OnlyFiles
+	shown.OnlyRelations = res.OnlyRelations
+	shown.OnlyModels = res.OnlyModels
+	shown.Meta = res.Meta
+	shown.Meta.Version = version()
+	shown.Meta.ConfigHash = configHash(flags)
+	shown.Meta.Options = resolvedOptions(flags)
+	shown.MaxErrors = maxErrors
+	if stats {
+		shown.Stats = report.Stats(results, 5)
+	}
+	fail := failures(results, failOn) > maxErrors
+	shown.Verdict = "pass"
+	if fail {
+		shown.Verdict = "fail"
 	}
 
-	if filterFile != "" {
-		var filtered []models.PreloadResult
-		for _, r := range results {
-			if r.File == filterFile {
-				filtered = append(filtered, r)
-			}
+	// Under GitHub Actions, annotations win over -f's implied json; -f still
+	// writes the json artifact. An explicit -o or -f - opts out.
+	if templates != nil {
+		outputFormat = "template"
+	} else if !flags.Changed("format") {
+		if os.Getenv("GITHUB_ACTIONS") == "true" && outputFile != output.Stdout {
+			outputFormat = "github"
+		} else if outputFile != "" {
+			outputFormat = "json"
 		}
-		results = filtered
 	}
 
-	if outputFile != "" {
-		outputFormat = "json"
+	style := output.Style{ErrorsOnly: errorsOnly, Color: color, FailUnknown: failOn == "unknown", MaxErrors: maxErrors, Explain: explain, SummaryOnly: summaryOnly, Context: contextLines, ShowScope: showScope}
+	switch outputFormat {
+	case "json":
+		if err := writeOutput(orDefault(outputFile, "gpc_results.json"), func(path string) error {
+			return output.WriteStructuredOutput(shown, path)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "junit":
+		if err := writeOutput(orDefault(outputFile, "gpc_results.xml"), func(path string) error {
+			return output.WriteJUnitOutput(shown, path)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "gitlab":
+		if err := writeOutput(orDefault(outputFile, "gl-code-quality-report.json"), func(path string) error {
+			return output.WriteGitLabOutput(shown, path)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "rdjson", "rdjsonl":
+		if err := writeOutput(outputFile, func(path string) error {
+			return output.WriteRDJSONOutput(shown, path, outputFormat == "rdjsonl")
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "tap":
+		if err := writeOutput(outputFile, func(path string) error {
+			return output.WriteTAPOutput(shown, path, style)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "plain":
+		if err := writeOutput(outputFile, func(path string) error {
+			return output.WritePlainOutput(shown, path)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "template":
+		if err := writeOutput(outputFile, func(path string) error {
+			return output.WriteTemplateOutput(shown, templates, path)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "ndjson":
+		if err := stream.WriteSummary(shown); err != nil {
+			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+			return 1
+		}
+	case "github":
+		output.WriteGitHubOutput(shown, style)
+		if outputFile != "" {
+			if err := writeOutput(outputFile, func(path string) error {
+				return output.WriteStructuredOutput(shown, path)
+			}); err != nil {
+				fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
+				return 1
+			}
+		}
+	default:
+		output.WriteConsoleOutput(shown, style)
 	}
 
-	if outputFormat == "json" {
-		dest := outputFile
-		if dest == "" {
-			dest = "gpc_results.json"
+	if metricsFile != "" {
+		cwd, err := os.Getwd()
+		if err == nil {
+			err = writeOutput(metricsFile, func(path string) error {
+				return output.WriteMetrics(report.ByDirectory(results, cwd), path)
+			})
 		}
-		if err := output.WriteStructuredOutput(results, dest, validationOnly, errorsOnly); err != nil {
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "gpc: %v\n", err)
-			os.Exit(1)
+			return 1
+		}
+	}
+
+	if fail {
+		return 2
+	}
+	return 0
+}
+
+// stdinTargets replaces a "-" target with the Go files listed on r, one
+// path per line (git diff --name-only | gpc -). Other files are ignored and
+// missing ones are warned about on warn, so deleted files don't fail a
+// hook. An empty list is no targets at all.
+func stdinTargets(targets []string, r io.Reader, warn io.Writer) ([]string, error) {
+	var out []string
+	read := false
+	for _, t := range targets {
+		if t != "-" {
+			out = append(out, t)
+			continue
+		}
+		if read {
+			return nil, errors.New(`"-" given more than once`)
+		}
+		read = true
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			path := strings.TrimSpace(sc.Text())
+			if !strings.HasSuffix(path, ".go") {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				fmt.Fprintf(warn, "gpc: warning: %s: skipped: %v\n", path, errors.Unwrap(err))
+				continue
+			}
+			out = append(out, path)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// changedLines keeps the results on lines the diff changed.
+func changedLines(results []models.PreloadResult, diff *gitdiff.Diff) []models.PreloadResult {
+	var kept []models.PreloadResult
+	for _, r := range results {
+		if diff.Contains(r.File, r.Line) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// lineRange is a --lines range of 1-based lines, both ends included.
+type lineRange struct{ start, end int }
+
+// parseLineRange parses a --lines start:end spec and checks that the range
+// lies within file.
+func parseLineRange(spec, file string) (*lineRange, error) {
+	startStr, endStr, ok := strings.Cut(spec, ":")
+	start, err1 := strconv.Atoi(startStr)
+	end, err2 := strconv.Atoi(endStr)
+	if !ok || err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid --lines %q (want start:end, e.g. 10:40)", spec)
+	}
+	r := &lineRange{start: start, end: end}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	n := len(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+	if r.start < 1 || r.start > r.end || r.end > n {
+		return nil, fmt.Errorf("--lines %s is not a range within %s (lines 1 to %d)", spec, file, n)
+	}
+	return r, nil
+}
+
+// filter keeps the results on lines within r.
+func (r *lineRange) filter(results []models.PreloadResult) []models.PreloadResult {
+	var kept []models.PreloadResult
+	for _, res := range results {
+		if res.Line >= r.start && res.Line <= r.end {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
+
+// loadConfig applies the settings of --config's file, or of the nearest
+// .gpc.yaml up to the module root, to every flag not given on the command
+// line. The search starts at the first target when it is a file or
+// directory, and at the current directory otherwise. Unknown keys are
+// warned about.
+func loadConfig(flags *pflag.FlagSet, targets []string) error {
+	path := configPath
+	if path == "" {
+		start, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if len(targets) > 0 {
+			if fi, err := os.Stat(targets[0]); err == nil {
+				start = targets[0]
+				if !fi.IsDir() {
+					start = filepath.Dir(start)
+				}
+			}
+		}
+		if path, err = config.Find(start); err != nil || path == "" {
+			return err
+		}
+	}
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, key := range file.Keys {
+		flag := flags.Lookup(key)
+		if flag == nil || !configurable(flag) {
+			unknown = append(unknown, key)
+			continue
+		}
+		if flag.Changed {
+			continue
+		}
+		values := file.Values[key]
+		if sv, ok := flag.Value.(pflag.SliceValue); ok {
+			err = sv.Replace(values)
+		} else {
+			err = flags.Set(key, strings.Join(values, ","))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	if len(unknown) > 0 {
+		var valid []string
+		flags.VisitAll(func(f *pflag.Flag) {
+			if configurable(f) {
+				valid = append(valid, f.Name)
+			}
+		})
+		fmt.Fprintf(os.Stderr, "gpc: warning: %s: unknown key(s) %s (valid keys: %s)\n",
+			path, strings.Join(unknown, ", "), strings.Join(valid, ", "))
+	}
+	return nil
+}
+
+// version is gpc's module version from the build info: the release for
+// a go install build, "(devel)" for a source build.
+func version() string {
+	if info, ok := runtimedebug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// outputFlags only choose where output goes and how it looks, so
+// resolvedOptions leaves them out.
+var outputFlags = map[string]bool{
+	"format": true, "file": true, "metrics-file": true, "format-template": true,
+	"summary-template": true, "mkdir": true, "color": true, "no-color": true,
+	"debug": true, "verbose": true, "log-file": true, "context": true,
+	"show-scope": true, "no-progress": true, "config": true, "help": true,
+}
+
+// resolvedOptions returns every other flag's effective value, from the
+// command line, the config file, --strict or its default, by name.
+func resolvedOptions(flags *pflag.FlagSet) map[string]string {
+	opts := map[string]string{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !outputFlags[f.Name] {
+			opts[f.Name] = f.Value.String()
+		}
+	})
+	return opts
+}
+
+// optionsLine spells resolvedOptions as name=value pairs in name order,
+// for --debug.
+func optionsLine(flags *pflag.FlagSet) string {
+	opts := resolvedOptions(flags)
+	pairs := make([]string, 0, len(opts))
+	for _, name := range slices.Sorted(maps.Keys(opts)) {
+		pairs = append(pairs, name+"="+opts[name])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// configHash fingerprints a run's settings: the SHA-256 of
+// resolvedOptions as name=value lines in name order. Runs with the same
+// hash and version analyzed the same way.
+func configHash(flags *pflag.FlagSet) string {
+	h := sha256.New()
+	opts := resolvedOptions(flags)
+	for _, name := range slices.Sorted(maps.Keys(opts)) {
+		fmt.Fprintf(h, "%s=%s\n", name, opts[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// configurable reports whether a flag can be set from a config file.
+func configurable(f *pflag.Flag) bool {
+	return f.Name != "config" && f.Name != "help"
+}
+
+func orDefault(path, def string) string {
+	if path == "" {
+		return def
+	}
+	return path
+}
+
+// writeOutput calls write for path, first creating path's directory when
+// --mkdir is set. A missing directory without --mkdir gets a hint.
+func writeOutput(path string, write func(string) error) error {
+	if mkdir && path != output.Stdout {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+	}
+	err := write(path)
+	if errors.Is(err, fs.ErrNotExist) && !mkdir {
+		return fmt.Errorf("%w (use --mkdir to create missing directories)", err)
+	}
+	return err
+}
+
+// applyPreset sets every flag in preset that was not changed on the
+// command line or in the config file. A changed key=value flag, such as
+// --severity, gains the preset's keys it doesn't set.
+func applyPreset(flags *pflag.FlagSet, preset map[string]string) {
+	for name, value := range preset {
+		if !flags.Changed(name) {
+			_ = flags.Set(name, value)
+			continue
+		}
+		set, err := flags.GetStringToString(name)
+		if err != nil {
+			continue
+		}
+		for _, pair := range strings.Split(value, ",") {
+			if key, _, _ := strings.Cut(pair, "="); set[key] == "" {
+				_ = flags.Set(name, pair)
+			}
+		}
+	}
+}
+
+// failures counts the results that fail the run under the given --fail-on
+// policy. Suppressed findings never count.
+func failures(results []models.PreloadResult, failOn string) int {
+	if failOn == "never" {
+		return 0
+	}
+	n := 0
+	for _, r := range results {
+		if r.Status == "error" || (r.Status == "unknown" && failOn == "unknown") {
+			n++
 		}
-	} else {
-		output.WriteConsoleOutput(results, validationOnly, errorsOnly)
 	}
+	return n
 }
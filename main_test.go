@@ -0,0 +1,898 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/testutil"
+)
+
+// parseFlags parses args into rootCmd's flags and restores every flag to
+// its default when the test ends.
+func parseFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := rootCmd.Flags()
+	t.Cleanup(func() {
+		flags.VisitAll(func(f *pflag.Flag) {
+			if sv, ok := f.Value.(pflag.SliceValue); ok {
+				var def []string
+				if d := strings.Trim(f.DefValue, "[]"); d != "" {
+					def = strings.Split(d, ",")
+				}
+				_ = sv.Replace(def)
+			} else if f.Value.Type() != "stringToString" {
+				_ = f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		})
+		// A set key=value flag merges later values into its map, so it
+		// is emptied instead
+		severity = map[string]string{}
+	})
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	return flags
+}
+
+func TestApplyPreset_Strict(t *testing.T) {
+	flags := parseFlags(t, "--strict")
+	applyPreset(flags, strictPreset)
+
+	if !warnDynamic {
+		t.Error("expected --strict to enable --warn-dynamic")
+	}
+	if failOn != "unknown" {
+		t.Errorf("expected --strict to set --fail-on=unknown, got %q", failOn)
+	}
+	for _, kind := range []string{"not-preloadable", "malformed-path"} {
+		if severity[kind] != "error" {
+			t.Errorf("expected --strict to pin %s findings as errors, got %v", kind, severity)
+		}
+	}
+}
+
+func TestApplyPreset_ExplicitFlagsWin(t *testing.T) {
+	flags := parseFlags(t, "--strict", "--warn-dynamic=false", "--fail-on=never", "--severity", "malformed-path=warning,not-found=warning")
+	applyPreset(flags, strictPreset)
+
+	if warnDynamic {
+		t.Error("expected explicit --warn-dynamic=false to override --strict")
+	}
+	if failOn != "never" {
+		t.Errorf("expected explicit --fail-on=never to override --strict, got %q", failOn)
+	}
+	// --severity overrides the kinds it names and keeps the preset's others
+	want := map[string]string{"malformed-path": "warning", "not-found": "warning", "not-preloadable": "error"}
+	if !maps.Equal(severity, want) {
+		t.Errorf("expected --severity %v over --strict, got %v", want, severity)
+	}
+}
+
+func TestExecute_StrictOptions(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "gpc.log")
+	dest := filepath.Join(t.TempDir(), "out.json")
+	flags := parseFlags(t, "--strict", "--fail-on=never", "--debug", "--log-file", logPath, "-f", dest, "examples/basic.go")
+	if code := execute(flags, flags.Args()...); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got models.AnalysisResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta == nil {
+		t.Fatal("expected meta")
+	}
+	opts := got.Meta.Options
+	want := map[string]string{
+		"strict":       "true",
+		"warn-dynamic": "true",
+		"fail-on":      "never",
+		"severity":     "[malformed-path=error,not-preloadable=error]",
+	}
+	for name, value := range want {
+		if opts[name] != value {
+			t.Errorf("meta options: %s=%q, want %q", name, opts[name], value)
+		}
+	}
+	if _, ok := opts["file"]; ok {
+		t.Error("expected output flags left out of the meta options")
+	}
+
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "gpc: debug: options: ") || !strings.Contains(string(log), " fail-on=never ") {
+		t.Errorf("expected the resolved options in the debug log:\n%s", log)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	withError := []models.PreloadResult{{Status: "valid"}, {Status: "error"}}
+	withUnknown := []models.PreloadResult{{Status: "valid"}, {Status: "unknown"}}
+	withDynamic := []models.PreloadResult{{Status: "dynamic"}, {Status: "warning"}}
+	mixed := []models.PreloadResult{{Status: "error"}, {Status: "unknown"}, {Status: "error"}, {Status: "suppressed"}}
+
+	tests := []struct {
+		name    string
+		results []models.PreloadResult
+		failOn  string
+		want    int
+	}{
+		{"error fails by default", withError, "error", 1},
+		{"unknown passes by default", withUnknown, "error", 0},
+		{"unknown fails on unknown", withUnknown, "unknown", 1},
+		{"dynamic never fails", withDynamic, "unknown", 0},
+		{"never ignores errors", withError, "never", 0},
+		{"skipped never fails", []models.PreloadResult{{Status: "skipped"}}, "unknown", 0},
+		{"errors counted", mixed, "error", 2},
+		{"errors and unknowns counted", mixed, "unknown", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failures(tt.results, tt.failOn); got != tt.want {
+				t.Errorf("failures() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_OutputFile(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+}
+`,
+	})
+	out := t.TempDir()
+
+	t.Run("missing directory", func(t *testing.T) {
+		dest := filepath.Join(out, "reports", "gpc.json")
+		flags := parseFlags(t, "-f", dest)
+		if code := execute(flags, dir); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Errorf("expected no output file, stat err = %v", err)
+		}
+	})
+
+	t.Run("mkdir", func(t *testing.T) {
+		dest := filepath.Join(out, "nested", "reports", "gpc.json")
+		flags := parseFlags(t, "-f", dest, "--mkdir")
+		if code := execute(flags, dir); code != 0 {
+			t.Errorf("expected exit code 0, got %d", code)
+		}
+		if _, err := os.Stat(dest); err != nil {
+			t.Errorf("expected output file: %v", err)
+		}
+	})
+
+	t.Run("destination is a directory", func(t *testing.T) {
+		dest := filepath.Join(out, "taken")
+		if err := os.MkdirAll(filepath.Join(dest, "child"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		flags := parseFlags(t, "-f", dest)
+		if code := execute(flags, dir); code != 1 {
+			t.Errorf("expected exit code 1, got %d", code)
+		}
+		leftovers, _ := filepath.Glob(filepath.Join(out, ".gpc-*"))
+		if len(leftovers) != 0 {
+			t.Errorf("expected no partial files, found %v", leftovers)
+		}
+	})
+}
+
+// TestExecute_ExitCodes pins the exit-code contract on the examples: 0
+// when nothing fails, 1 when the run itself fails, 2 when preloads do.
+func TestExecute_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "valid", args: []string{"examples/basic.go"}, want: 0},
+		{name: "typos", args: []string{"examples/errors.go"}, want: 2},
+		{name: "typos with fail-on never", args: []string{"--fail-on", "never", "examples/errors.go"}, want: 0},
+		{name: "typos among other targets", args: []string{"examples/basic.go", "examples/complex.go"}, want: 2},
+		{name: "bad target", args: []string{"./no/such/dir/..."}, want: 1},
+		{name: "bad fail-on", args: []string{"--fail-on", "sometimes", "examples/basic.go"}, want: 1},
+		{name: "typos within max-errors", args: []string{"--max-errors", "20", "examples/errors.go"}, want: 0},
+		{name: "typos over max-errors", args: []string{"--max-errors", "1", "examples/errors.go"}, want: 2},
+		{name: "bad max-errors", args: []string{"--max-errors", "-1", "examples/basic.go"}, want: 1},
+		{name: "bad context", args: []string{"--context", "-1", "examples/basic.go"}, want: 1},
+		{name: "bad max-has-many", args: []string{"--warn-has-many", "--max-has-many", "0", "examples/basic.go"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := parseFlags(t, append(tt.args, "-o", "json", "-f", filepath.Join(t.TempDir(), "out.json"))...)
+			if code := execute(flags, flags.Args()...); code != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestExecute_Verdict(t *testing.T) {
+	for _, tt := range []struct {
+		maxErrors string
+		want      string
+	}{{"0", "fail"}, {"50", "pass"}} {
+		dest := filepath.Join(t.TempDir(), "out.json")
+		flags := parseFlags(t, "--max-errors", tt.maxErrors, "-f", dest, "examples/errors.go")
+		execute(flags, flags.Args()...)
+		data, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got models.AnalysisResult
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Verdict != tt.want || strconv.Itoa(got.MaxErrors) != tt.maxErrors {
+			t.Errorf("--max-errors %s: verdict %q, max_errors %d; want %q", tt.maxErrors, got.Verdict, got.MaxErrors, tt.want)
+		}
+	}
+}
+
+func TestExecute_Meta(t *testing.T) {
+	var hashes []string
+	for _, maxErrors := range []string{"0", "50", "50"} {
+		dest := filepath.Join(t.TempDir(), "out.json")
+		flags := parseFlags(t, "--max-errors", maxErrors, "-f", dest, "examples/errors.go")
+		execute(flags, flags.Args()...)
+		data, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got models.AnalysisResult
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		// A file target counts only itself, as --list-files lists it
+		m := got.Meta
+		if m == nil || m.Version == "" || len(m.ConfigHash) != 64 || m.Files != 1 || m.Structs == 0 || len(m.Targets) != 1 || m.Targets[0] != "examples/errors.go" {
+			t.Fatalf("unexpected meta %+v", m)
+		}
+		hashes = append(hashes, m.ConfigHash)
+	}
+	// -f differs every run; only --max-errors counts
+	if hashes[0] == hashes[1] || hashes[1] != hashes[2] {
+		t.Errorf("expected the hash to follow --max-errors only, got %v", hashes)
+	}
+}
+
+func TestExecute_Strict(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+// Load's model can't be resolved: its Preload is unknown
+func Load(db *gorm.DB, dest interface{}) {
+	db.Preload("User").Find(dest)
+}
+`,
+		"dynamic/dynamic.go": `package dynamic
+
+import "gorm.io/gorm"
+
+type Order struct {
+	ID int64
+}
+
+func Get(db *gorm.DB, rel string) {
+	var orders []Order
+	db.Preload(rel).Find(&orders)
+}
+`,
+	})
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "unknown passes by default", args: []string{dir}, want: 0},
+		{name: "strict fails on unknown", args: []string{"--strict", dir}, want: 2},
+		{name: "strict within max-errors", args: []string{"--strict", "--max-errors", "1", dir}, want: 0},
+		{name: "dynamic exempt under strict", args: []string{"--strict", filepath.Join(dir, "dynamic")}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := parseFlags(t, append(tt.args, "-o", "json", "-f", filepath.Join(t.TempDir(), "out.json"))...)
+			if code := execute(flags, flags.Args()...); code != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestExecute_WarnDuplicate(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func List(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Preload("User").Find(&orders)
+}
+`,
+	})
+	for _, tt := range []struct {
+		args     []string
+		warnings int
+	}{
+		{nil, 1},
+		{[]string{"--warn-duplicate"}, 1},
+		{[]string{"--warn-duplicate=false"}, 0},
+	} {
+		dest := filepath.Join(t.TempDir(), "out.json")
+		flags := parseFlags(t, append(tt.args, "-f", dest, dir)...)
+		if code := execute(flags, flags.Args()...); code != 0 {
+			t.Fatalf("%v: exit code %d", tt.args, code)
+		}
+		data, err := os.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got models.AnalysisResult
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Warnings != tt.warnings || got.Valid != 2-tt.warnings {
+			t.Errorf("%v: got %d warning(s), %d valid; want %d warning(s)", tt.args, got.Warnings, got.Valid, tt.warnings)
+		}
+	}
+}
+
+func TestExecute_DisplayFilters(t *testing.T) {
+	tests := []struct {
+		flag   string
+		status map[string]bool
+	}{
+		{"--errors-only", map[string]bool{"error": true}},
+		{"--validation-only", map[string]bool{"valid": true, "error": true, "warning": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flag, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "out.json")
+			flags := parseFlags(t, tt.flag, "-f", dest, "examples/with_conditions.go")
+			execute(flags, flags.Args()...)
+			data, err := os.ReadFile(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got models.AnalysisResult
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range got.Results {
+				if !tt.status[r.Status] {
+					t.Errorf("unexpected %s result %s:%d", r.Status, r.File, r.Line)
+				}
+			}
+			if got.Displayed != len(got.Results) || got.Displayed >= got.Total || got.Errors == 0 {
+				t.Errorf("expected counts over every result: total %d, errors %d, displayed %d of %d",
+					got.Total, got.Errors, got.Displayed, len(got.Results))
+			}
+		})
+	}
+}
+
+func TestExecute_LogFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "gpc.log")
+	flags := parseFlags(t, "--debug", "-v", "--log-file", logPath, "-o", "json", "-f", filepath.Join(t.TempDir(), "out.json"), "examples/basic.go")
+	if code := execute(flags, flags.Args()...); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"gpc: debug: load ", "gpc: debug: verify: ", "gpc: verbose: "} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in the log file:\n%s", want, data)
+		}
+	}
+}
+
+func TestExecute_GitHubArtifact(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	path := filepath.Join(t.TempDir(), "out.json")
+	flags := parseFlags(t, "-f", path, "examples/basic.go")
+	if code := execute(flags, flags.Args()...); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if outputFormat != "github" {
+		t.Errorf("expected github output under GitHub Actions, got %q", outputFormat)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected -f to still write the json artifact: %v", err)
+	}
+	var res models.AnalysisResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Total == 0 {
+		t.Error("expected results in the json artifact")
+	}
+}
+
+func TestExecute_NDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	flags := parseFlags(t, "-o", "ndjson", "-f", path, "-e", "examples/")
+	if code := execute(flags, flags.Args()...); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	var summary struct {
+		Type   string `json:"type"`
+		Errors int    `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil || summary.Type != "summary" {
+		t.Fatalf("expected a summary line last, got %q (%v)", lines[len(lines)-1], err)
+	}
+	if summary.Errors == 0 || len(lines)-1 != summary.Errors {
+		t.Errorf("expected a line per error with -e, got %d line(s) for %d error(s)", len(lines)-1, summary.Errors)
+	}
+	for _, line := range lines[:len(lines)-1] {
+		var r models.PreloadResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.Status != "error" {
+			t.Errorf("expected an error result, got %q (%v)", line, err)
+		}
+	}
+}
+
+func TestExecute_ListFiles(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+
+	// Errors in the listed files don't matter: nothing is analyzed
+	flags := parseFlags(t, "--list-files", "--exclude", "with_*.go", "examples/")
+	code := execute(flags, flags.Args()...)
+	os.Stdout = stdout
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "examples/basic.go\nexamples/complex.go\nexamples/errors.go\n"
+	if got := filepath.ToSlash(string(data)); got != want {
+		t.Errorf("stdout:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExecute_JSONToStdout(t *testing.T) {
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	flags := parseFlags(t, "-f", "-", "examples/basic.go")
+	code := execute(flags, flags.Args()...)
+	os.Stdout = stdout
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res models.AnalysisResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("expected only the json document on stdout, got %q: %v", data, err)
+	}
+	if res.Total == 0 {
+		t.Error("expected results in the json document")
+	}
+
+	flags = parseFlags(t, "-o", "github", "-f", "-", "examples/basic.go")
+	if code := execute(flags, flags.Args()...); code != 1 {
+		t.Errorf("expected exit code 1 for -o github -f -, got %d", code)
+	}
+}
+
+func TestExecute_FormatTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	flags := parseFlags(t, "--format-template", "short", "--summary-template", "{{.Total}} checked", "-f", path, "examples/basic.go")
+	if code := execute(flags, flags.Args()...); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "examples/basic.go:34: User valid\n" +
+		"examples/basic.go:37: User.Profile valid\n" +
+		"examples/basic.go:40: User.Profile.Address valid\n" +
+		"3 checked\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestExecute_FormatTemplateErrors(t *testing.T) {
+	tests := [][]string{
+		{"--format-template", "{{.Fle}}"},
+		{"--format-template", "short", "-o", "json"},
+		{"--summary-template", "{{.Total}}"},
+	}
+	for _, args := range tests {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			flags := parseFlags(t, append(args, "examples/basic.go")...)
+			if code := execute(flags, flags.Args()...); code != 1 {
+				t.Errorf("expected exit code 1, got %d", code)
+			}
+		})
+	}
+}
+
+func TestExecute_Lines(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantCode  int
+		wantTotal int
+	}{
+		{[]string{"--lines", "36:40", "examples/basic.go"}, 0, 2},
+		{[]string{"--lines", "1:41", "examples/basic.go"}, 0, 3},
+		{[]string{"--lines", "36:42", "examples/basic.go"}, 1, 0},
+		{[]string{"--lines", "40:36", "examples/basic.go"}, 1, 0},
+		{[]string{"--lines", "36", "examples/basic.go"}, 1, 0},
+		{[]string{"--lines", "1:2", "examples/"}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.json")
+			flags := parseFlags(t, append([]string{"-f", path}, tt.args...)...)
+			if code := execute(flags, flags.Args()...); code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d", tt.wantCode, code)
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res models.AnalysisResult
+			if err := json.Unmarshal(data, &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Total != tt.wantTotal {
+				t.Errorf("expected %d preload(s) in range, got %d", tt.wantTotal, res.Total)
+			}
+		})
+	}
+}
+
+func TestExecute_SummaryOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	flags := parseFlags(t, "--summary-only", "-f", path, "examples/")
+	if code := execute(flags, flags.Args()...); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res models.AnalysisResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Results == nil || len(res.Results) != 0 || res.Displayed != 0 {
+		t.Errorf("expected an empty results array, got %d result(s), displayed %d", len(res.Results), res.Displayed)
+	}
+	if res.Total == 0 || res.Errors == 0 || res.Verdict != "fail" {
+		t.Errorf("expected the counts of the whole run, got total %d, errors %d, verdict %q", res.Total, res.Errors, res.Verdict)
+	}
+}
+
+func TestExecute_Stdin(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"good.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+}
+`,
+		"bad.go": `package main
+
+import "gorm.io/gorm"
+
+func GetMore(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Customer").Find(&orders)
+}
+`,
+	})
+	t.Chdir(dir)
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "empty", input: "", want: 0},
+		{name: "non-go and missing files", input: "README.md\ngone.go\n\ngood.go\n", want: 0},
+		{name: "failing file", input: "good.go\nbad.go\n", want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = strings.NewReader(tt.input)
+			t.Cleanup(func() { stdin = os.Stdin })
+			flags := parseFlags(t, "-o", "json", "-f", filepath.Join(t.TempDir(), "out.json"))
+			if code := execute(flags, "-"); code != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestExecute_Diff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	base := `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Customer").Find(&orders)
+}
+`
+	dir := testutil.CreateTestModule(t, map[string]string{"main.go": base, "other.go": "package main\n"})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-qm", "base")
+	git("checkout", "-qb", "feature")
+	changed := base + `
+func GetMore(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("rm", "-q", "other.go")
+	git("commit", "-qam", "change")
+	t.Chdir(dir)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		// The old invalid preload is in a changed file
+		{name: "files", args: []string{"--diff", "main"}, want: 2},
+		// but not on a changed line
+		{name: "lines", args: []string{"--diff", "main", "--diff-lines"}, want: 0},
+		{name: "no changes", args: []string{"--diff", "feature"}, want: 0},
+		{name: "unknown ref", args: []string{"--diff", "nope"}, want: 1},
+		{name: "lines without diff", args: []string{"--diff-lines", "."}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := parseFlags(t, append(tt.args, "-o", "json", "-f", filepath.Join(t.TempDir(), "out.json"))...)
+			if code := execute(flags, flags.Args()...); code != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestStdinTargets(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "gone.go")
+	var warn strings.Builder
+	got, err := stdinTargets([]string{"./pkg", "-"}, strings.NewReader(file+"\n"+missing+"\nnotes.txt\n"), &warn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"./pkg", file}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+	if !strings.Contains(warn.String(), missing) || strings.Contains(warn.String(), "notes.txt") {
+		t.Errorf("unexpected warnings %q", warn.String())
+	}
+	if _, err := stdinTargets([]string{"-", "-"}, strings.NewReader(""), &warn); err == nil {
+		t.Error("expected an error for a repeated -")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(root, "go.mod"), "module example.com/app\n")
+	writeFile(filepath.Join(root, ".gpc.yaml"), `format: json
+fail-on: never
+finishers: [FindInBatches, FetchAll]
+`)
+	other := filepath.Join(root, "ci", "gpc.yaml")
+	writeFile(other, "format: junit\n")
+
+	t.Run("discovered from a subdirectory", func(t *testing.T) {
+		t.Chdir(filepath.Join(root, "ci"))
+		flags := parseFlags(t)
+		if err := loadConfig(flags, nil); err != nil {
+			t.Fatalf("loadConfig: %v", err)
+		}
+		if outputFormat != "json" || failOn != "never" {
+			t.Errorf("expected file values, got format=%s fail-on=%s", outputFormat, failOn)
+		}
+		if strings.Join(finishers, ",") != "FindInBatches,FetchAll" {
+			t.Errorf("expected finishers from file, got %v", finishers)
+		}
+	})
+
+	t.Run("flags override the file", func(t *testing.T) {
+		t.Chdir(root)
+		flags := parseFlags(t, "-o", "github", "--finishers", "Fetch")
+		if err := loadConfig(flags, nil); err != nil {
+			t.Fatalf("loadConfig: %v", err)
+		}
+		if outputFormat != "github" || failOn != "never" {
+			t.Errorf("expected format from flag and fail-on from file, got format=%s fail-on=%s", outputFormat, failOn)
+		}
+		if strings.Join(finishers, ",") != "Fetch" {
+			t.Errorf("expected finishers from flag, got %v", finishers)
+		}
+	})
+
+	t.Run("explicit config skips discovery", func(t *testing.T) {
+		t.Chdir(root)
+		flags := parseFlags(t, "--config", other)
+		if err := loadConfig(flags, nil); err != nil {
+			t.Fatalf("loadConfig: %v", err)
+		}
+		if outputFormat != "junit" || failOn != "error" {
+			t.Errorf("expected only the explicit file's values, got format=%s fail-on=%s", outputFormat, failOn)
+		}
+	})
+
+	t.Run("discovered from the target", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		target := filepath.Join(root, "repo", "order.go")
+		writeFile(target, "package repo\n")
+		flags := parseFlags(t)
+		if err := loadConfig(flags, []string{target}); err != nil {
+			t.Fatalf("loadConfig: %v", err)
+		}
+		if outputFormat != "json" {
+			t.Errorf("expected the target module's file, got format=%s", outputFormat)
+		}
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		bare := t.TempDir()
+		writeFile(filepath.Join(bare, "go.mod"), "module example.com/bare\n")
+		t.Chdir(bare)
+		flags := parseFlags(t)
+		if err := loadConfig(flags, []string{"./..."}); err != nil {
+			t.Fatalf("loadConfig: %v", err)
+		}
+		if outputFormat != "text" || failOn != "error" {
+			t.Errorf("expected defaults, got format=%s fail-on=%s", outputFormat, failOn)
+		}
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		flags := parseFlags(t, "--config", filepath.Join(root, "missing.yaml"))
+		if err := loadConfig(flags, nil); err == nil {
+			t.Error("expected an error for a missing --config file")
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		bad := filepath.Join(root, "bad.yaml")
+		writeFile(bad, "max-has-many: lots\nunknown-key: 1\n")
+		flags := parseFlags(t, "--config", bad)
+		if err := loadConfig(flags, nil); err == nil {
+			t.Error("expected an error for a non-integer max-has-many")
+		}
+	})
+}
@@ -0,0 +1,278 @@
+// Package gpc is the Go API of the GORM preload checker. Analyze runs the
+// same verification as the gpc command and returns the results instead of
+// printing them.
+package gpc
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/your-moon/gpc/internal/collector"
+	"github.com/your-moon/gpc/internal/engine"
+	"github.com/your-moon/gpc/internal/exclude"
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/relations"
+	"github.com/your-moon/gpc/internal/report"
+)
+
+// PreloadResult is the outcome of verifying one relation path.
+type PreloadResult = models.PreloadResult
+
+// AnalysisResult holds the results of a run and their per-status counts.
+type AnalysisResult = models.AnalysisResult
+
+// Options configures Analyze. The zero value matches running the gpc
+// command with no flags.
+type Options struct {
+	// ValidationOnly keeps only verified results (valid, error, warning)
+	// in Results. The counts still cover every result.
+	ValidationOnly bool
+	// ErrorsOnly keeps only errors in Results.
+	ErrorsOnly bool
+	// IncludeTests also analyzes _test.go files.
+	IncludeTests bool
+	// IncludeVendor also analyzes vendored packages that the analyzed
+	// packages import. vendor, testdata, and directories starting with "."
+	// or "_" are skipped otherwise.
+	IncludeVendor bool
+	// WarnDynamic reports dynamic relation arguments as warnings.
+	WarnDynamic bool
+	// WarnRedundant reports parents already loaded by a nested preload.
+	WarnRedundant bool
+	// AllowDuplicates stops reporting a relation preloaded twice in the
+	// same chain, a "duplicate-preload" warning otherwise.
+	AllowDuplicates bool
+	// MaxHasManyHops, when positive, warns on paths crossing more has-many
+	// relations than this.
+	MaxHasManyHops int
+	// PreloadFields lists struct field name patterns whose []string
+	// literals hold relation names. Nil means {"Preloads"}.
+	PreloadFields []string
+	// Models, when non-empty, verifies only models matching these
+	// path.Match patterns; preloads on other models are "skipped".
+	Models []string
+	// IgnoreBareNolint keeps a //nolint without linter names from
+	// suppressing findings.
+	IgnoreBareNolint bool
+	// Finishers names extra methods that run a query, like Find and First.
+	Finishers []string
+	// IgnoreModels and IgnoreRelations hold patterns (like Models, and
+	// matched against the whole relation path) whose findings are reported
+	// as "suppressed".
+	IgnoreModels    []string
+	IgnoreRelations []string
+	// Severity overrides the status of findings by kind: "error",
+	// "warning" or "info".
+	Severity map[string]string
+	// Exclude holds glob patterns of files whose preloads are not checked,
+	// relative to the analyzed directory; "**" matches any number of
+	// directories ("**/mocks/**", "internal/legacy/*.go").
+	Exclude []string
+	// OnlyFiles, when non-empty, reports only results in files matching
+	// these patterns, matched like Exclude. Every file is still analyzed,
+	// so models resolve as usual, but the counts cover only the reported
+	// results and AnalysisResult.OnlyFiles says so.
+	OnlyFiles []string
+	// OnlyRelations and OnlyModels, when non-empty, likewise report only
+	// results whose relation path, or model, matches one of these
+	// path.Match patterns ("Items*" matches "Items.Product"; "Invoice" or
+	// "db.Invoice"). AnalysisResult records them too.
+	OnlyRelations []string
+	OnlyModels    []string
+	// Funcs, when non-empty, checks only preloads in functions matching
+	// these path.Match patterns: "List", or "Repo.List" and "List" for a
+	// method.
+	Funcs []string
+	// ScalarTypes names extra types, as "package/path.Name", that hold a
+	// column value rather than a relation (time.Time, sql.NullString and
+	// gorm.DeletedAt are built in).
+	ScalarTypes []string
+	// CheckSelectColumns checks the columns selected in Preload scope
+	// functions against the relation's struct.
+	CheckSelectColumns bool
+	// Explain sets each result's Explain trace of how its model was
+	// resolved.
+	Explain bool
+	// Debug, when set, receives timestamped diagnostic lines about the run:
+	// per-pass counts and timings, and --exclude skip counts.
+	Debug io.Writer
+	// Verbose, when set, receives a timestamped line per verified preload.
+	Verbose io.Writer
+	// Progress, when set, is called as each load advances: stage "load"
+	// before its packages load, then "collect" per file reached (done of
+	// total). Several targets may run several loads.
+	Progress func(stage string, done, total int)
+	// OnFile, when set, receives each analyzed file's results, before
+	// ValidationOnly and ErrorsOnly filter them, as soon as the file is
+	// verified, so they can be streamed before Analyze returns. A file may
+	// be reported with no results.
+	OnFile func(filename string, results []PreloadResult)
+}
+
+// Analyze verifies every Preload relation path under target and returns
+// the filtered results with the counts of all of them. It writes nothing
+// except diagnostic lines to opts.Debug and opts.Verbose.
+//
+// target is a directory, a single Go file (results are narrowed to that
+// file), or a package pattern resolved from the current directory's module.
+func Analyze(target string, opts Options) (*AnalysisResult, error) {
+	return AnalyzeTargets([]string{target}, opts)
+}
+
+// AnalyzeTargets is Analyze over several targets, merged into one result.
+// Targets in the same module are loaded together, so they share one view
+// of its types; each result is still attributed to its own file. The
+// result's Meta describes the run, without Version and ConfigHash.
+func AnalyzeTargets(targets []string, opts Options) (*AnalysisResult, error) {
+	for kind, level := range opts.Severity {
+		if level != "error" && level != "warning" && level != "info" {
+			return nil, fmt.Errorf("invalid severity %q for %s (want error, warning, or info)", level, kind)
+		}
+	}
+	resolved := make([]target, len(targets))
+	for i, t := range targets {
+		var err error
+		if resolved[i], err = resolveTarget(t); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	fields := opts.PreloadFields
+	if fields == nil {
+		fields = []string{"Preloads"}
+	}
+	var results []models.PreloadResult
+	skippedTests := 0
+	reported := map[string]bool{}
+	// Files and structs each count once, however many loads saw them
+	scanned := map[string]bool{}
+	structs := map[string]int{}
+	for _, l := range planLoads(resolved) {
+		if len(opts.OnlyFiles) > 0 {
+			only, err := exclude.New(l.dir, opts.OnlyFiles)
+			if err != nil {
+				return nil, err
+			}
+			keep := l.keep
+			l.keep = func(file string) bool { return keep(file) && only.Matches(file) }
+		}
+		var onFile func(string, []models.PreloadResult)
+		if opts.OnFile != nil {
+			onFile = func(filename string, results []models.PreloadResult) {
+				if !reported[filename] && l.keep(filename) {
+					opts.OnFile(filename, report.Select(results, opts.OnlyRelations, opts.OnlyModels))
+				}
+			}
+		}
+		run, err := engine.Analyze(l.dir, engine.Options{
+			Patterns:      l.patterns,
+			Tests:         opts.IncludeTests,
+			Exclude:       opts.Exclude,
+			Funcs:         opts.Funcs,
+			IncludeVendor: opts.IncludeVendor,
+			Debug:         opts.Debug,
+			Verbose:       opts.Verbose,
+			Progress:      opts.Progress,
+			OnFile:        onFile,
+			Collect: collector.Options{
+				OptionFields:     fields,
+				TerminalMethods:  opts.Finishers,
+				IgnoreBareNolint: opts.IgnoreBareNolint,
+			},
+			Verify: relations.Options{
+				MaxHasManyHops:     opts.MaxHasManyHops,
+				DynamicAsWarning:   opts.WarnDynamic,
+				RedundantParents:   opts.WarnRedundant,
+				AllowDuplicates:    opts.AllowDuplicates,
+				Models:             opts.Models,
+				IgnoreModels:       opts.IgnoreModels,
+				IgnoreRelations:    opts.IgnoreRelations,
+				Severity:           opts.Severity,
+				ScalarTypes:        opts.ScalarTypes,
+				CheckSelectColumns: opts.CheckSelectColumns,
+				Explain:            opts.Explain,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		skippedTests += run.SkippedTestFiles
+		for _, file := range run.Files {
+			if !reported[file] && l.keep(file) {
+				scanned[file] = true
+			}
+		}
+		maps.Copy(structs, run.Structs)
+
+		// A file another load already reported was covered twice
+		seen := map[string]bool{}
+		for _, r := range run.Results {
+			if reported[r.File] || !l.keep(r.File) {
+				continue
+			}
+			seen[r.File] = true
+			results = append(results, report.Select([]models.PreloadResult{r}, opts.OnlyRelations, opts.OnlyModels)...)
+		}
+		for file := range seen {
+			reported[file] = true
+		}
+	}
+
+	res := report.Build(results, opts.ValidationOnly, opts.ErrorsOnly)
+	res.SkippedTestFiles = skippedTests
+	res.OnlyFiles = opts.OnlyFiles
+	res.OnlyRelations = opts.OnlyRelations
+	res.OnlyModels = opts.OnlyModels
+	total := 0
+	for _, n := range structs {
+		total += n
+	}
+	res.Meta = &models.Meta{
+		StartedAt:  start.UTC().Truncate(time.Millisecond),
+		DurationMS: time.Since(start).Milliseconds(),
+		Targets:    targets,
+		Files:      len(scanned),
+		Structs:    total,
+	}
+	return res, nil
+}
+
+// ListFiles returns the Go files AnalyzeTargets would check for targets,
+// sorted, without analyzing them: those of the loaded packages that the
+// targets cover and opts.Exclude leaves in, _test.go files only under
+// opts.IncludeTests. Only the options that choose files are used.
+func ListFiles(targets []string, opts Options) ([]string, error) {
+	resolved := make([]target, len(targets))
+	for i, t := range targets {
+		var err error
+		if resolved[i], err = resolveTarget(t); err != nil {
+			return nil, err
+		}
+	}
+	seen := map[string]bool{}
+	var files []string
+	for _, l := range planLoads(resolved) {
+		loaded, err := engine.Files(l.dir, engine.Options{
+			Patterns:      l.patterns,
+			Tests:         opts.IncludeTests,
+			Exclude:       opts.Exclude,
+			IncludeVendor: opts.IncludeVendor,
+			Debug:         opts.Debug,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range loaded {
+			if !seen[file] && l.keep(file) {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
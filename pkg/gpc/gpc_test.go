@@ -0,0 +1,500 @@
+package gpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/your-moon/gpc/internal/report"
+	"github.com/your-moon/gpc/internal/testutil"
+)
+
+func TestAnalyze(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"main.go": `package main
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	ID   int64
+	User User
+}
+
+func GetOrders(db *gorm.DB) {
+	var orders []Order
+	db.Preload("User").Find(&orders)
+	db.Preload("Usr").Find(&orders)
+}
+`,
+		"main_test.go": `package main
+
+import "gorm.io/gorm"
+
+func loadFixture(db *gorm.DB) {
+	var orders []Order
+	db.Preload("Customer").Find(&orders)
+}
+`,
+	})
+
+	res, err := Analyze(dir, Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if res.Total != 2 || res.Valid != 1 || res.Errors != 1 {
+		t.Errorf("expected 2 total, 1 valid, 1 error; got %+v", res)
+	}
+
+	res, err = Analyze(dir, Options{ErrorsOnly: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if res.Displayed != 1 || res.Results[0].Relation != "Usr" {
+		t.Errorf("errors-only: expected only Usr, got %+v", res.Results)
+	}
+	if res.Total != 2 || res.Valid != 1 || res.Errors != 1 {
+		t.Errorf("errors-only: expected counts over all results, got %+v", res)
+	}
+
+	res, err = Analyze(dir, Options{IncludeTests: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if res.Total != 3 || res.Errors != 2 {
+		t.Errorf("with tests: expected 3 total, 2 errors; got %+v", res)
+	}
+
+	res, err = Analyze(filepath.Join(dir, "main_test.go"), Options{IncludeTests: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if res.Total != 1 || res.Results[0].Relation != "Customer" {
+		t.Errorf("single file: expected only Customer, got %+v", res.Results)
+	}
+}
+
+func TestAnalyze_SingleFileModelsElsewhere(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"geo/geo.go": `package geo
+
+type City struct {
+	Name string
+}
+
+type Location struct {
+	City City
+}
+`,
+		"models/trip.go": `package models
+
+import "testmod/geo"
+
+type Trip struct {
+	ID          int64
+	Origin      geo.Location
+	Destination *geo.Location
+}
+`,
+		"handlers/trip.go": `package handlers
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func Trips(db *gorm.DB) {
+	var trips []models.Trip
+	db.Preload("Origin.City").Preload("Destination.Cty").Find(&trips)
+}
+`,
+	})
+
+	// Only the handler is a target; its models resolve through its imports
+	res, err := Analyze(filepath.Join(dir, "handlers", "trip.go"), Options{})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if res.Total != 2 || res.Valid != 1 || res.Errors != 1 || res.Unknown != 0 {
+		t.Fatalf("expected 1 valid and 1 error, got %+v", res)
+	}
+	for _, r := range res.Results {
+		if r.Model != "models.Trip" {
+			t.Errorf("%s: expected model models.Trip, got %s", r.Relation, r.Model)
+		}
+	}
+}
+
+func TestAnalyzeTargets(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"models/models.go": `package models
+
+type User struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID   int64
+	User User
+}
+`,
+		"services/trips/trips.go": `package trips
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+type page struct {
+	Size int
+}
+
+func List(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("User").Find(&invoices)
+}
+`,
+		"services/invoices/invoices.go": `package invoices
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func List(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("Usr").Find(&invoices)
+}
+`,
+		"handlers/machine.go": `package handlers
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func Machine(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("User").Find(&invoices)
+}
+`,
+		"handlers/other.go": `package handlers
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func Other(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("Nope").Find(&invoices)
+}
+`,
+		"cmd/cmd.go": `package cmd
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func Run(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("Nope").Find(&invoices)
+}
+`,
+	})
+
+	var streamed []PreloadResult
+	res, err := AnalyzeTargets([]string{
+		filepath.Join(dir, "services", "trips"),
+		filepath.Join(dir, "services", "invoices"),
+		filepath.Join(dir, "handlers", "machine.go"),
+		filepath.Join(dir, "services"), // overlaps the first two
+	}, Options{OnFile: func(filename string, results []PreloadResult) {
+		streamed = append(streamed, results...)
+	}})
+	if err != nil {
+		t.Fatalf("AnalyzeTargets: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, res.Results) {
+		t.Errorf("OnFile streamed %+v, want the results %+v", streamed, res.Results)
+	}
+	// Only the target files count, and the trips package once though
+	// two targets load it
+	if m := res.Meta; m == nil || m.Files != 3 || m.Structs != 1 {
+		t.Errorf("expected meta to count 3 files and 1 struct, got %+v", m)
+	}
+
+	got := map[string]string{}
+	for _, r := range res.Results {
+		rel, _ := filepath.Rel(dir, r.File)
+		got[filepath.ToSlash(rel)+":"+r.Relation] = r.Status
+	}
+	want := map[string]string{
+		"services/trips/trips.go:User":      "valid",
+		"services/invoices/invoices.go:Usr": "error",
+		"handlers/machine.go:User":          "valid",
+	}
+	if len(res.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d: %v", len(want), len(res.Results), got)
+	}
+	for key, status := range want {
+		if got[key] != status {
+			t.Errorf("%s: expected %s, got %q", key, status, got[key])
+		}
+	}
+
+	// A package pattern and a path target are separate loads; the files
+	// they share are still reported once
+	t.Chdir(dir)
+	res, err = AnalyzeTargets([]string{"./services/...", filepath.Join(dir, "services", "trips")}, Options{})
+	if err != nil {
+		t.Fatalf("AnalyzeTargets: %v", err)
+	}
+	if res.Total != 2 || res.Errors != 1 {
+		t.Errorf("expected 2 results with 1 error, got %d with %d", res.Total, res.Errors)
+	}
+}
+
+func TestAnalyzeTargets_Deterministic(t *testing.T) {
+	files := map[string]string{
+		"models/models.go": `package models
+
+type User struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID   int64
+	User User
+}
+`,
+	}
+	for _, name := range []string{"zeta", "alpha", "mid"} {
+		files[name+"/"+name+".go"] = `package ` + name + `
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func List(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("Usr").Preload("User").Find(&invoices)
+	db.Preload("User").Find(&invoices); db.Preload("Nope").Find(&invoices)
+}
+`
+	}
+	dir := testutil.CreateTestModule(t, files)
+
+	var runs [][]byte
+	for range 2 {
+		res, err := AnalyzeTargets([]string{dir}, Options{})
+		if err != nil {
+			t.Fatalf("AnalyzeTargets: %v", err)
+		}
+		if !slices.IsSortedFunc(res.Results, report.Compare) {
+			t.Errorf("results are not sorted by file, line, column and relation: %+v", res.Results)
+		}
+		// Meta tells the runs apart by their time
+		if m := res.Meta; m == nil || m.Files != 4 || m.Structs != 2 || !slices.Equal(m.Targets, []string{dir}) || m.StartedAt.IsZero() {
+			t.Errorf("unexpected meta %+v", m)
+		}
+		res.Meta = nil
+		data, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, data)
+	}
+	if !bytes.Equal(runs[0], runs[1]) {
+		t.Errorf("two runs differ:\n%s\n%s", runs[0], runs[1])
+	}
+}
+
+func TestAnalyzeTargets_OnlyFilters(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"models/models.go": `package models
+
+type User struct {
+	ID int64
+}
+
+type Invoice struct {
+	ID   int64
+	User User
+}
+`,
+		"handlers/machine.go": `package handlers
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func Machine(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("User").Find(&invoices)
+	db.Preload("Usr").Find(&invoices)
+}
+`,
+		"repo/repo.go": `package repo
+
+import (
+	"gorm.io/gorm"
+
+	"testmod/models"
+)
+
+func List(db *gorm.DB) {
+	var invoices []models.Invoice
+	db.Preload("Nope").Find(&invoices)
+}
+`,
+	})
+
+	var streamed []string
+	res, err := AnalyzeTargets([]string{dir}, Options{
+		OnlyFiles: []string{"handlers/**"},
+		OnFile:    func(filename string, _ []PreloadResult) { streamed = append(streamed, filepath.Base(filename)) },
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTargets: %v", err)
+	}
+	if res.Total != 2 || res.Valid != 1 || res.Errors != 1 || res.Accuracy != 0.5 {
+		t.Errorf("expected the counts of handlers/ only (2 total, 1 valid, 1 error), got %d, %d, %d", res.Total, res.Valid, res.Errors)
+	}
+	for _, r := range res.Results {
+		if filepath.Base(r.File) != "machine.go" {
+			t.Errorf("unexpected result outside handlers/: %s", r.File)
+		}
+	}
+	if !slices.Equal(streamed, []string{"machine.go"}) {
+		t.Errorf("expected only machine.go streamed, got %v", streamed)
+	}
+	if !slices.Equal(res.OnlyFiles, []string{"handlers/**"}) {
+		t.Errorf("expected OnlyFiles to record the patterns, got %v", res.OnlyFiles)
+	}
+
+	if _, err := AnalyzeTargets([]string{dir}, Options{OnlyFiles: []string{"a/[b"}}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+
+	var selected []PreloadResult
+	res, err = AnalyzeTargets([]string{dir}, Options{
+		OnlyRelations: []string{"Us*", "Nope"},
+		OnlyModels:    []string{"Invoice"},
+		ErrorsOnly:    true,
+		OnFile:        func(_ string, results []PreloadResult) { selected = append(selected, results...) },
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeTargets: %v", err)
+	}
+	if res.Total != 3 || res.Errors != 2 || len(res.Results) != 2 || len(selected) != 3 {
+		t.Errorf("expected User, Usr and Nope counted and their 2 errors shown, got %d total, %d error(s), %d shown, %d streamed", res.Total, res.Errors, len(res.Results), len(selected))
+	}
+	if !slices.Equal(res.OnlyRelations, []string{"Us*", "Nope"}) || !slices.Equal(res.OnlyModels, []string{"Invoice"}) {
+		t.Errorf("expected the patterns recorded, got %v and %v", res.OnlyRelations, res.OnlyModels)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	dir := testutil.CreateTestModule(t, map[string]string{
+		"models/models.go":      "package models\n\ntype User struct{ ID int64 }\n",
+		"models/models_test.go": "package models\n",
+		"repo/repo.go":          "package repo\n",
+		"repo/mocks/mock.go":    "package mocks\n",
+		"handlers/machine.go":   "package handlers\n",
+		"handlers/other.go":     "package handlers\n",
+	})
+
+	tests := []struct {
+		name    string
+		targets []string
+		opts    Options
+		want    []string
+	}{
+		{
+			name:    "directory",
+			targets: []string{dir},
+			want:    []string{"handlers/machine.go", "handlers/other.go", "models/models.go", "repo/mocks/mock.go", "repo/repo.go"},
+		},
+		{
+			name:    "exclude and tests",
+			targets: []string{dir},
+			opts:    Options{Exclude: []string{"**/mocks/**"}, IncludeTests: true},
+			want:    []string{"handlers/machine.go", "handlers/other.go", "models/models.go", "models/models_test.go", "repo/repo.go"},
+		},
+		{
+			name:    "file and overlapping directories",
+			targets: []string{filepath.Join(dir, "handlers", "machine.go"), filepath.Join(dir, "repo"), filepath.Join(dir, "repo", "mocks")},
+			want:    []string{"handlers/machine.go", "repo/mocks/mock.go", "repo/repo.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ListFiles(tt.targets, tt.opts)
+			if err != nil {
+				t.Fatalf("ListFiles: %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, _ := filepath.Rel(dir, file)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanLoads(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	for _, dir := range []string{root, other} {
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(root, "services", "a")
+	b := filepath.Join(root, "services", "b")
+
+	loads := planLoads([]target{
+		{path: a},
+		{path: filepath.Join(b, "b.go"), file: true},
+		{pattern: "example.com/m/..."},
+		{path: other},
+	})
+	if len(loads) != 3 {
+		t.Fatalf("expected 3 loads, got %d", len(loads))
+	}
+	if want := filepath.Join(root, "services"); loads[1].dir != want {
+		t.Errorf("expected load from %s, got %s", want, loads[1].dir)
+	}
+	if got := strings.Join(loads[1].patterns, " "); got != "./a/... ./b" {
+		t.Errorf("unexpected patterns %q", got)
+	}
+	if !loads[1].keep(filepath.Join(b, "b.go")) || loads[1].keep(filepath.Join(b, "c.go")) {
+		t.Error("expected only the file target to be kept in its package")
+	}
+	if loads[2].dir != other || strings.Join(loads[2].patterns, " ") != "./..." {
+		t.Errorf("expected a lone directory to load ./... from itself, got %s %v", loads[2].dir, loads[2].patterns)
+	}
+}
@@ -0,0 +1,142 @@
+package gpc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// target is a resolved command-line target: an existing directory or file
+// (path is absolute), or a package pattern.
+type target struct {
+	path    string
+	file    bool
+	pattern string
+}
+
+// resolveTarget classifies a target. An existing file or directory is
+// analyzed in place (a file narrows the report to that file); anything
+// else is treated as a package pattern or import path and resolved from
+// the current directory's module.
+func resolveTarget(t string) (target, error) {
+	info, err := os.Stat(t)
+	switch {
+	case err == nil:
+		path, err := filepath.Abs(t)
+		return target{path: path, file: !info.IsDir()}, err
+	case isPackagePattern(t):
+		return target{pattern: t}, nil
+	}
+	return target{}, err
+}
+
+// isPackagePattern reports whether a non-existent target can still name
+// packages: a "..." wildcard or an import path.
+func isPackagePattern(target string) bool {
+	if strings.Contains(target, "...") {
+		return true
+	}
+	return !filepath.IsAbs(target) && !strings.HasPrefix(target, ".") && !strings.HasSuffix(target, ".go")
+}
+
+// load is one engine run: the packages to load from dir, and which of
+// their files to report.
+type load struct {
+	dir      string
+	patterns []string
+	keep     func(file string) bool
+}
+
+// planLoads groups targets into as few loads as possible. Package patterns
+// load together from the current directory. Files and directories load per
+// module, from the deepest directory holding them all, so a lone directory
+// target loads "./..." from itself as it always has.
+func planLoads(targets []target) []load {
+	var loads []load
+	var patterns []string
+	var modules []string
+	byModule := map[string][]target{}
+	for _, t := range targets {
+		if t.path == "" {
+			patterns = append(patterns, t.pattern)
+			continue
+		}
+		mod := moduleRoot(targetDir(t))
+		if _, ok := byModule[mod]; !ok {
+			modules = append(modules, mod)
+		}
+		byModule[mod] = append(byModule[mod], t)
+	}
+
+	if len(patterns) > 0 {
+		dir, _ := filepath.Abs(".")
+		loads = append(loads, load{dir: dir, patterns: patterns, keep: func(string) bool { return true }})
+	}
+	for _, mod := range modules {
+		group := byModule[mod]
+		base := targetDir(group[0])
+		for _, t := range group[1:] {
+			base = commonDir(base, targetDir(t))
+		}
+		l := load{dir: base}
+		for _, t := range group {
+			rel, _ := filepath.Rel(base, targetDir(t))
+			pattern := "."
+			if rel != "." {
+				pattern = "./" + filepath.ToSlash(rel)
+			}
+			if !t.file {
+				pattern += "/..."
+			}
+			l.patterns = append(l.patterns, pattern)
+		}
+		l.keep = func(file string) bool {
+			for _, t := range group {
+				if t.file && file == t.path || !t.file && strings.HasPrefix(file, t.path+string(filepath.Separator)) {
+					return true
+				}
+			}
+			return false
+		}
+		loads = append(loads, l)
+	}
+	return loads
+}
+
+// targetDir is the directory a target's packages live in.
+func targetDir(t target) string {
+	if t.file {
+		return filepath.Dir(t.path)
+	}
+	return t.path
+}
+
+// moduleRoot returns the nearest directory at or above dir with a go.mod,
+// or dir itself when there is none.
+func moduleRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// commonDir returns the deepest directory containing both a and b.
+func commonDir(a, b string) string {
+	for {
+		rel, err := filepath.Rel(a, b)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return a
+		}
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
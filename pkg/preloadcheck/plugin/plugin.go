@@ -0,0 +1,80 @@
+// Command plugin builds preloadcheck as a golangci-lint Go plugin:
+//
+//	go build -buildmode=plugin -o preloadcheck.so ./pkg/preloadcheck/plugin
+//
+// golangci-lint loads New from the .so. Build it with the same Go version
+// and dependency versions as the golangci-lint binary, or loading fails.
+// Then register it in .golangci.yml:
+//
+//	version: "2"
+//	linters:
+//	  enable:
+//	    - preloadcheck
+//	  settings:
+//	    custom:
+//	      preloadcheck:
+//	        type: goplugin
+//	        path: ./preloadcheck.so
+//	        description: Checks GORM Preload relation paths
+//	        original-url: github.com/your-moon/gpc
+//	        settings:
+//	          severity: warning          # error (default) or warning
+//	          preload-methods: [WithPreload]
+//	          preload-fields: [Preloads, "*Relations"]
+//	          scalar-types: [github.com/acme/money.Amount]
+//	          skip-tests: true
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/your-moon/gpc/pkg/preloadcheck"
+)
+
+// Settings is the plugin's settings block in .golangci.yml.
+type Settings struct {
+	// Severity is "error" (default) to report invalid preloads only, or
+	// "warning" to also report warnings and unverifiable preloads.
+	Severity string `json:"severity"`
+	// PreloadMethods names extra methods to treat like Preload.
+	PreloadMethods []string `json:"preload-methods"`
+	// PreloadFields lists struct field name patterns holding relation
+	// names. Empty means {"Preloads"}.
+	PreloadFields []string `json:"preload-fields"`
+	// ScalarTypes names extra types ("package/path.Name") that hold a
+	// column value rather than a relation.
+	ScalarTypes []string `json:"scalar-types"`
+	// SkipTests leaves preloads in _test.go files unchecked.
+	SkipTests bool `json:"skip-tests"`
+}
+
+// New is the golangci-lint plugin entrypoint. conf is the decoded settings
+// block (nil when there is none).
+func New(conf any) ([]*analysis.Analyzer, error) {
+	var s Settings
+	if conf != nil {
+		data, err := json.Marshal(conf)
+		if err != nil {
+			return nil, fmt.Errorf("preloadcheck: settings: %w", err)
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("preloadcheck: settings: %w", err)
+		}
+	}
+	if s.Severity != "" && s.Severity != "error" && s.Severity != "warning" {
+		return nil, fmt.Errorf("preloadcheck: invalid severity %q (want error or warning)", s.Severity)
+	}
+
+	cfg := preloadcheck.Config{Severity: s.Severity, PreloadMethods: s.PreloadMethods, ScalarTypes: s.ScalarTypes, SkipTests: s.SkipTests}
+	if len(s.PreloadFields) > 0 {
+		cfg.PreloadFields = s.PreloadFields
+	}
+	return []*analysis.Analyzer{preloadcheck.NewAnalyzer(cfg)}, nil
+}
+
+// main is never called; it lets the package build outside
+// -buildmode=plugin.
+func main() {}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    any
+		wantErr bool
+	}{
+		{"no settings", nil, false},
+		{"empty settings", map[string]any{}, false},
+		{"severity", map[string]any{"severity": "warning"}, false},
+		{"bad severity", map[string]any{"severity": "fatal"}, true},
+		{"bad type", map[string]any{"preload-methods": "WithPreload"}, true},
+	}
+	for _, tt := range tests {
+		analyzers, err := New(tt.conf)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: New() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (len(analyzers) != 1 || analyzers[0].Name != "preloadcheck") {
+			t.Errorf("%s: expected the preloadcheck analyzer, got %v", tt.name, analyzers)
+		}
+	}
+}
+
+func TestNew_SettingsReachAnalyzer(t *testing.T) {
+	analyzers, err := New(map[string]any{
+		"severity":        "warning",
+		"preload-methods": []any{"WithPreload"},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	testdata, err := filepath.Abs("../testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, testdata, analyzers[0], "configured")
+}
@@ -0,0 +1,156 @@
+// Package preloadcheck exposes gpc's Preload verification as a go/analysis
+// Analyzer, for use with multichecker, go vet -vettool, or golangci-lint
+// (see the plugin subpackage).
+package preloadcheck
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/your-moon/gpc/internal/collector"
+	"github.com/your-moon/gpc/internal/loader"
+	"github.com/your-moon/gpc/internal/models"
+	"github.com/your-moon/gpc/internal/relations"
+)
+
+// Analyzer reports invalid GORM Preload relation paths with the default
+// Config. Like the CLI, it honors //gpc:ignore, //gpc:ignore-file and
+// //nolint:gpc (or :preloadcheck) directives.
+var Analyzer = NewAnalyzer(Config{})
+
+// Config tunes an Analyzer. The zero value reports invalid preloads only.
+type Config struct {
+	// Severity selects what is reported: "error" (or empty) reports invalid
+	// preloads; "warning" also reports warnings and preloads that could not
+	// be verified.
+	Severity string
+	// PreloadMethods names extra methods whose first argument is a relation
+	// path, like Preload's.
+	PreloadMethods []string
+	// PreloadFields lists struct field name patterns whose []string
+	// literals hold relation names. Nil means {"Preloads"}.
+	PreloadFields []string
+	// IgnoreBareNolint keeps a //nolint without linter names from
+	// silencing findings; //nolint:gpc and //nolint:preloadcheck always do.
+	IgnoreBareNolint bool
+	// ScalarTypes names extra types, as "package/path.Name", that hold a
+	// column value rather than a relation, like the built-in time.Time.
+	ScalarTypes []string
+	// SkipTests leaves preloads in _test.go files unchecked. Types declared
+	// in them still resolve. The Analyzer's -skip-tests flag sets it too.
+	SkipTests bool
+}
+
+// Result is an Analyzer's tally for one package, available to analyzers
+// that require it.
+type Result struct {
+	// Preloads counts the relation paths checked, suppressed ones included.
+	Preloads int
+	// Dynamic counts those whose relation argument is not a constant
+	// (a parameter, a function result, a concatenation with a variable),
+	// which can't be verified and are never reported.
+	Dynamic int
+}
+
+// NewAnalyzer returns an Analyzer configured by cfg.
+func NewAnalyzer(cfg Config) *analysis.Analyzer {
+	a := &analysis.Analyzer{
+		Name:       "preloadcheck",
+		Doc:        "check that GORM Preload relation paths name fields of the queried model",
+		URL:        "https://github.com/your-moon/gpc",
+		ResultType: reflect.TypeFor[*Result](),
+		Run: func(pass *analysis.Pass) (any, error) {
+			return run(pass, cfg), nil
+		},
+	}
+	a.Flags.BoolVar(&cfg.SkipTests, "skip-tests", cfg.SkipTests, "don't check preloads in _test.go files")
+	return a
+}
+
+func run(pass *analysis.Pass, cfg Config) *Result {
+	fields := cfg.PreloadFields
+	if fields == nil {
+		fields = []string{"Preloads"}
+	}
+	syntax := pass.Files
+	if cfg.SkipTests {
+		syntax = nil
+		for _, f := range pass.Files {
+			if !strings.HasSuffix(pass.Fset.File(f.Pos()).Name(), "_test.go") {
+				syntax = append(syntax, f)
+			}
+		}
+	}
+	pkg := &packages.Package{
+		ID:        pass.Pkg.Path(),
+		Name:      pass.Pkg.Name(),
+		PkgPath:   pass.Pkg.Path(),
+		Fset:      pass.Fset,
+		Syntax:    syntax,
+		Types:     pass.Pkg,
+		TypesInfo: pass.TypesInfo,
+	}
+	chains := collector.Collect(&loader.Result{Packages: []*packages.Package{pkg}}, collector.Options{
+		OptionFields:     fields,
+		PreloadMethods:   cfg.PreloadMethods,
+		IgnoreBareNolint: cfg.IgnoreBareNolint,
+	})
+
+	files := map[string]*token.File{}
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		files[tf.Name()] = tf
+	}
+
+	res := &Result{}
+	for _, r := range relations.Verify(chains, relations.Options{ScalarTypes: cfg.ScalarTypes}) {
+		res.Preloads++
+		if r.Status == "dynamic" {
+			res.Dynamic++
+		}
+		if !reported(r, cfg.Severity) {
+			continue
+		}
+		tf, ok := files[r.File]
+		if !ok || r.Line < 1 || r.Line > tf.LineCount() {
+			continue
+		}
+		pos := tf.LineStart(r.Line)
+		if r.Column > 0 && tf.Offset(pos)+r.Column-1 <= tf.Size() {
+			pos += token.Pos(r.Column - 1)
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:      pos,
+			Category: r.Kind,
+			Message:  message(r),
+		})
+	}
+	return res
+}
+
+// reported reports whether a result is reported under the given severity.
+func reported(r models.PreloadResult, severity string) bool {
+	switch r.Status {
+	case "error":
+		return true
+	case "warning", "unknown":
+		return severity == "warning"
+	}
+	return false
+}
+
+// message phrases a result the way the console output does.
+func message(r models.PreloadResult) string {
+	switch r.Status {
+	case "error":
+		return "invalid preload: " + r.Message
+	case "unknown":
+		return fmt.Sprintf("%s not verified: %s", r.Relation, r.Message)
+	}
+	return r.Message
+}
@@ -0,0 +1,31 @@
+package preloadcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	results := analysistest.Run(t, analysistest.TestData(), Analyzer, "basic")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	res, ok := results[0].Result.(*Result)
+	if !ok || res.Preloads != 7 || res.Dynamic != 1 {
+		t.Errorf("expected 7 preloads with 1 dynamic, got %+v", results[0].Result)
+	}
+}
+
+func TestNewAnalyzer_Config(t *testing.T) {
+	a := NewAnalyzer(Config{Severity: "warning", PreloadMethods: []string{"WithPreload"}})
+	analysistest.Run(t, analysistest.TestData(), a, "configured")
+}
+
+func TestNewAnalyzer_SkipTestsFlag(t *testing.T) {
+	a := NewAnalyzer(Config{})
+	if err := a.Flags.Set("skip-tests", "true"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), a, "skiptests")
+}
@@ -0,0 +1,31 @@
+package basic
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	Profile Profile
+}
+
+type Order struct {
+	User User
+}
+
+func GetOrders(db *gorm.DB, rel string) {
+	var orders []Order
+	db.Preload("User.Profile").Find(&orders)
+	db.Preload("Usr").Find(&orders)          // want `invalid preload: Usr not found in basic.Order \(did you mean "User"\?\)`
+	db.Preload("user.profile").Find(&orders) // want `invalid preload: user.profile not found in basic.Order \(did you mean "User.Profile"\?\)`
+	db.Preload(rel).Find(&orders)
+	db.Preload("Custmer").Find(&orders) //gpc:ignore legacy
+	db.Preload("Custmr").Find(&orders)  //nolint:preloadcheck
+
+	db.Preload(fmt.Sprintf("%s.Profil", "User")).Find(&orders) // want `invalid preload: User.Profil not found in basic.Order`
+}
@@ -0,0 +1,23 @@
+package configured
+
+import "gorm.io/gorm"
+
+type User struct {
+	ID int64
+}
+
+type Order struct {
+	User User
+}
+
+type Repo struct {
+	*gorm.DB
+}
+
+func (r Repo) WithPreload(rel string) Repo { return r }
+
+func GetOrders(r Repo) {
+	var orders []Order
+	r.WithPreload("Customer").Find(&orders)         // want `invalid preload: Customer not found in configured.Order`
+	r.Preload("User").Preload("User").Find(&orders) // want `duplicate preload of User`
+}
@@ -0,0 +1,12 @@
+package skiptests
+
+import "gorm.io/gorm"
+
+type User struct {
+	Name string
+}
+
+func GetUsers(db *gorm.DB) {
+	var users []User
+	db.Preload("Profile").Find(&users) // want `invalid preload: Profile not found in skiptests.User`
+}
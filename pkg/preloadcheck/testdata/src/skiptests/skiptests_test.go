@@ -0,0 +1,12 @@
+package skiptests
+
+import "gorm.io/gorm"
+
+type fixture struct {
+	User User
+}
+
+func seed(db *gorm.DB) {
+	var rows []fixture
+	db.Preload("Usr").Find(&rows)
+}